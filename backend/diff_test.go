@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numbered returns the lines 1 to n, with the lines in change replaced.
+func numbered(n int, change map[int]string) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		if s, ok := change[i]; ok {
+			sb.WriteString(s)
+		} else {
+			fmt.Fprintf(&sb, "%d\n", i)
+		}
+	}
+	return sb.String()
+}
+
+// The expected diffs are those of GNU diff -u.
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name, a, b, want string
+	}{
+		{"equal", "a\n", "a\n", ""},
+		{"empty old", "", "a\nb\n", `--- f.orig
++++ f
+@@ -0,0 +1,2 @@
++a
++b
+`},
+		{"empty new", "a\nb\n", "", `--- f.orig
++++ f
+@@ -1,2 +0,0 @@
+-a
+-b
+`},
+		{"no final newline", "a\nb", "a\nc\n", `--- f.orig
++++ f
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++c
+`},
+		{"final newline removed", "a\n", "a", `--- f.orig
++++ f
+@@ -1 +1 @@
+-a
++a
+\ No newline at end of file
+`},
+		// Changes separated by 2*diffContext lines share a hunk.
+		{"close changes", numbered(20, nil), numbered(20, map[int]string{5: "x\n", 12: "y\n"}), `--- f.orig
++++ f
+@@ -2,14 +2,14 @@
+ 2
+ 3
+ 4
+-5
++x
+ 6
+ 7
+ 8
+ 9
+ 10
+ 11
+-12
++y
+ 13
+ 14
+ 15
+`},
+		{"far changes", numbered(20, nil), numbered(20, map[int]string{5: "x\n", 13: "y\n"}), `--- f.orig
++++ f
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++x
+ 6
+ 7
+ 8
+@@ -10,7 +10,7 @@
+ 10
+ 11
+ 12
+-13
++y
+ 14
+ 15
+ 16
+`},
+		{"insert", numbered(3, nil), numbered(3, map[int]string{2: "2\nnew\n"}), `--- f.orig
++++ f
+@@ -1,3 +1,4 @@
+ 1
+ 2
++new
+ 3
+`},
+	}
+	for _, tc := range tests {
+		if got := unifiedDiff("f", tc.a, tc.b); got != tc.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDiffLinesMaxEdits(t *testing.T) {
+	// Every other line changes, so the shortest diff keeps the even lines
+	// but takes more than maxDiffEdits edits.
+	n := maxDiffEdits + 200
+	change := map[int]string{}
+	for i := 1; i <= n; i += 2 {
+		change[i] = fmt.Sprintf("changed %d\n", i)
+	}
+	a, b := splitLines(numbered(n, nil)), splitLines(numbered(n, change))
+	ops := diffLines(a, b)
+	var got []string
+	for _, op := range ops {
+		switch op.kind {
+		case ' ':
+			// Only the common last line is kept.
+			if op.line != fmt.Sprintf("%d\n", n) {
+				t.Fatalf("unexpected common line %q", op.line)
+			}
+		case '+':
+			got = append(got, op.line)
+		}
+	}
+	if want := b[:len(b)-1]; strings.Join(got, "") != strings.Join(want, "") {
+		t.Fatalf("inserted lines don't match the new lines")
+	}
+	if len(ops) != 2*n-1 {
+		t.Fatalf("got %d ops, want %d", len(ops), 2*n-1)
+	}
+
+	// Below the limit, the shortest diff is found.
+	change = map[int]string{}
+	for i := 1; i <= 200; i += 2 {
+		change[i] = fmt.Sprintf("changed %d\n", i)
+	}
+	a, b = splitLines(numbered(200, nil)), splitLines(numbered(200, change))
+	common := 0
+	for _, op := range diffLines(a, b) {
+		if op.kind == ' ' {
+			common++
+		}
+	}
+	if common != 100 {
+		t.Fatalf("got %d common lines, want 100", common)
+	}
+}
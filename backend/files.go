@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mjibson/sqlfmt"
+)
+
+// errNotFormatted is returned by runFiles if -l or -d found input whose
+// formatting differs.
+var errNotFormatted = errors.New("input isn't formatted")
+
+// exitNotFormatted is the exit status for errNotFormatted.
+const exitNotFormatted = 3
+
+// runFiles formats the files and directories in paths, or stdin if there
+// are none, as gofmt does.
+func runFiles(paths []string) error {
+	if len(*flagStmts) > 0 || *flagStream || *flagLines != "" || *flagOffset != "" || *flagOutput != "text" {
+		return errors.New("file arguments, -w, -l and -d can't be used with --stmt, --stream, --lines, --offset or --output")
+	}
+	var warns []sqlfmt.Warning
+	failed, differs := 0, false
+	check := func(name string, in []byte, write bool) {
+		path := name
+		if name == "<stdin>" {
+			path = ""
+		}
+		opts, err := fileOptions(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed++
+			return
+		}
+		changed, w, err := formatFile(opts, name, in, write)
+		warns = append(warns, w...)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed++
+		}
+		differs = differs || changed
+	}
+	if len(paths) == 0 {
+		if *flagWrite {
+			return errors.New("-w needs file arguments")
+		}
+		in, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		check("<stdin>", in, false)
+	}
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// Files named in paths are always formatted, but only .sql
+			// files that aren't hidden are formatted in directories.
+			hidden := name != path && strings.HasPrefix(d.Name(), ".")
+			if d.IsDir() {
+				if hidden {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if name != path && (hidden || !strings.EqualFold(filepath.Ext(name), ".sql")) {
+				return nil
+			}
+			in, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			check(name, in, *flagWrite)
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d files couldn't be formatted", failed)
+	}
+	if err := checkWidth(warns); err != nil {
+		return err
+	}
+	if differs && !*flagWrite && (*flagList || *flagDiff) {
+		return errNotFormatted
+	}
+	return nil
+}
+
+// formatFile formats in, the content of the file name, and writes it back
+// if write is set. It reports whether formatting changed it.
+func formatFile(opts sqlfmt.Options, name string, in []byte, write bool) (bool, []sqlfmt.Warning, error) {
+	res, warns, err := format(string(in), opts)
+	for _, w := range warns {
+		fmt.Fprintf(os.Stderr, "%s:%v\n", name, w)
+	}
+	if err != nil {
+		return false, warns, errors.New(errorText(name, err))
+	}
+	changed := res != string(in)
+	if *flagList && changed {
+		fmt.Println(name)
+	}
+	if write && changed {
+		if err := writeFile(name, res); err != nil {
+			return changed, warns, err
+		}
+	}
+	if *flagDiff {
+		fmt.Print(unifiedDiff(name, string(in), res))
+	}
+	if !*flagList && !*flagWrite && !*flagDiff {
+		fmt.Print(res)
+	}
+	return changed, warns, nil
+}
+
+// writeFile atomically replaces the content of the file name with s,
+// keeping its permissions.
+func writeFile(name, s string) error {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".sqlfmt-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.WriteString(s)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), fi.Mode().Perm())
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -1,24 +1,25 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"crypto/tls"
 	gojson "encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io/ioutil"
 	"log"
-	"math/rand"
 	"net/http"
 	"os"
 	"os/signal"
-	"regexp"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
-	"sync"
 	"syscall"
-	"unicode"
+	"time"
 
-	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroachdb-parser/pkg/util/pretty"
 	"github.com/kelseyhightower/envconfig"
 	flag "github.com/spf13/pflag"
@@ -32,6 +33,15 @@ type Specification struct {
 	Redir    string
 	Autocert []string
 	DirCache string
+	// Timeout and MaxInput are the time and input size budget of each
+	// formatting request. A request that runs out of time is answered, but
+	// the statement being formatted is finished in the background.
+	Timeout  time.Duration `default:"5s"`
+	MaxInput int           `default:"1048576"`
+	// MaxFormats is the number of requests formatted at once, or 0 for the
+	// number of CPUs. Requests beyond it are refused with 503 Service
+	// Unavailable.
+	MaxFormats int
 }
 
 var (
@@ -41,11 +51,50 @@ var (
 	flagCasemode   = flag.String("casemode", "upper", "keyword casing, can be: upper, lower, title, spongebob")
 	flagNoSimplify = flag.Bool("no-simplify", false, "don't simplify the output")
 	flagAlign      = flag.Bool("align", false, "right-align keywords")
+	flagMaxBlank   = flag.Int("max-blank-lines", 1, "maximum number of consecutive blank lines in the output")
+	flagKeepBlank  = flag.Bool("keep-blank-lines", false, "keep the blank lines between statements instead of separating them by one")
+	flagCollapse   = flag.Bool("collapse-comment-gap", false, "remove blank lines between the comments before a statement and the statement")
+	flagLineEnding = flag.String("line-ending", "auto", "line ending, can be: auto (the most common one in the input), lf, crlf")
+	flagBOM        = flag.String("bom", "keep", "whether to start the output with a byte order mark, can be: keep, always, never")
+	flagFinalNL    = flag.String("final-newline", "always", "whether to end the output with a line ending, can be: always, never, keep")
+	flagAllErrors  = flag.BoolP("all-errors", "e", false, "report all parse errors, not just the first")
+	flagTolerant   = flag.Bool("tolerant", false, "leave statements that can't be parsed unformatted instead of failing")
+	flagLines      = flag.String("lines", "", "only format statements overlapping the 1-based, inclusive line range `first:last` of stdin")
+	flagOffset     = flag.String("offset", "", "only format statements overlapping the byte range `start:end` of stdin")
+	flagStream     = flag.Bool("stream", false, "write each statement from stdin as soon as it is formatted, for very large inputs")
+	flagVerify     = flag.Bool("verify", false, "check that formatting doesn't change the meaning of statements and is idempotent")
+	flagStrict     = flag.Bool("max-width-strict", false, "fail if a line of output is wider than --print-width")
+	flagJobs       = flag.IntP("jobs", "j", 0, "maximum number of statements to format at once, 0 for the number of CPUs")
+	flagCache      = flag.Bool("cache", false, "reuse the output for inputs formatted before with the same options, stored in --cache-dir")
+	flagCacheDir   = flag.String("cache-dir", "", "directory of the --cache, instead of the user's cache directory")
+	flagCacheSize  = flag.Int64("cache-size", 100<<20, "maximum size of the --cache in bytes, 0 for no limit")
+	flagOutput     = flag.String("output", "text", "output format, can be: text, json (a result with the span, formatted text, tag and error of each statement)")
+	flagWrite      = flag.BoolP("write", "w", false, "write the output to the formatted files instead of stdout")
+	flagList       = flag.BoolP("list", "l", false, "list the files whose formatting differs instead of writing the output")
+	flagDiff       = flag.BoolP("diff", "d", false, "print unified diffs of the formatting changes instead of the output")
+	flagEditorCfg  = flag.Bool("editorconfig", true, "read the indentation and line width of files from .editorconfig files, unless set by flags or config files")
+	flagShow       = flag.String("show", "", "with the config command, print the settings for `FILE` from its config files and the flags")
 	flagStmts      = flag.StringArray("stmt", nil, "instead of reading from stdin, specify statements as arguments")
 	flagHelp       = flag.BoolP("help", "h", false, "display help")
 	flagVersion    = flag.BoolP("version", "v", false, "display version")
 )
 
+// optionFlags are the flags that set sqlfmt.Options by name.
+var optionFlags = map[string]bool{
+	"print-width":          true,
+	"use-spaces":           true,
+	"tab-width":            true,
+	"casemode":             true,
+	"no-simplify":          true,
+	"align":                true,
+	"max-blank-lines":      true,
+	"keep-blank-lines":     true,
+	"collapse-comment-gap": true,
+	"line-ending":          true,
+	"bom":                  true,
+	"final-newline":        true,
+}
+
 var (
 	version = "dev"
 	commit  = "none"
@@ -60,15 +109,24 @@ func main() {
 
 %s runs in one of two modes.
 
-1) It takes in SQL statements from stdin or the --stmt arguments
-and formats them to stdout. This mode is enabled if the webserver is
-unconfigured.
+1) It takes in SQL statements from stdin, the --stmt arguments or
+the files named by its arguments, and formats them to stdout. Directory
+arguments are searched for .sql files. With -w, files are rewritten
+instead. With -l or -d, the files that aren't formatted are listed or
+their diffs printed, and the exit status is %[2]d if there are any. This
+mode is enabled if the webserver is unconfigured.
+
+Settings are read from %[3]s files in the directory of each
+file and the directories above it, and flags override them. The
+indentation and line width of files are also read from .editorconfig
+files, unless set by %[3]s files or flags. Run
+"%[1]s config --show FILE" to print the settings for FILE.
 
 2) It runs a webserver on a specified address. This is configured by
 setting the SQLFMT_ADDR env variable to a bindable address (like ":8080"):
 
 SQLFMT_ADDR=":8080" %[1]s
-`, os.Args[0])
+`, os.Args[0], exitNotFormatted, sqlfmt.ConfigFileName)
 		return
 	}
 	if *flagVersion {
@@ -86,55 +144,428 @@ SQLFMT_ADDR=":8080" %[1]s
 		return
 	}
 
-	if err := runCmd(); err != nil {
+	if err := runCmd(); errors.Is(err, errNotFormatted) {
+		os.Exit(exitNotFormatted)
+	} else if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
 }
 
 func runCmd() error {
-	if *flagPrintWidth < 1 {
-		return fmt.Errorf("line length must be > 0: %d", *flagPrintWidth)
+	if isConfigCmd(flag.Args()) {
+		return runConfig(flag.Args()[1:])
+	}
+	opts, err := fileOptions("")
+	if err != nil {
+		return err
 	}
-	if *flagTabWidth < 1 {
-		return fmt.Errorf("tab width must be > 0: %d", *flagTabWidth)
+
+	if *flagCache {
+		dir := *flagCacheDir
+		if dir == "" {
+			if dir, err = sqlfmt.DefaultCacheDir(); err != nil {
+				return fmt.Errorf("--cache: %v", err)
+			}
+		}
+		diskCache = sqlfmt.NewDiskCache(dir, *flagCacheSize)
+	}
+
+	if flag.NArg() > 0 || *flagWrite || *flagList || *flagDiff {
+		return runFiles(flag.Args())
 	}
-	if *flagCasemode != "" && caseModes[*flagCasemode] == nil {
-		return fmt.Errorf("unknown casemode: %s", *flagCasemode)
+
+	switch *flagOutput {
+	case "text":
+	case "json":
+		return runJSON(opts)
+	default:
+		return fmt.Errorf("--output: unknown format %q", *flagOutput)
+	}
+
+	if *flagLines != "" || *flagOffset != "" {
+		return runRange(opts)
+	}
+
+	if *flagStream {
+		if len(*flagStmts) > 0 {
+			return errors.New("--stream can't be used with --stmt")
+		}
+		out := bufio.NewWriter(os.Stdout)
+		warns, err := sqlfmt.FormatStream(context.Background(), out, os.Stdin, opts)
+		if ferr := out.Flush(); err == nil {
+			err = ferr
+		}
+		for _, w := range warns {
+			fmt.Fprintf(os.Stderr, "<stdin>:%v\n", w)
+		}
+		if err != nil {
+			return errors.New(errorText("<stdin>", err))
+		}
+		return checkWidth(warns)
 	}
 
-	sl := *flagStmts
-	if len(sl) == 0 {
+	if len(*flagStmts) == 0 {
 		in, err := ioutil.ReadAll(os.Stdin)
 		if err != nil {
 			return err
 		}
-		sl = append(sl, string(in))
+		r, warns, err := format(string(in), opts)
+		if err != nil {
+			return errors.New(errorText("<stdin>", err))
+		}
+		for _, w := range warns {
+			fmt.Fprintf(os.Stderr, "<stdin>:%v\n", w)
+		}
+		fmt.Print(r)
+		return checkWidth(warns)
 	}
 
-	cfg := tree.DefaultPrettyCfg()
-	cfg.UseTabs = !*flagUseSpaces
-	cfg.LineWidth = *flagPrintWidth
-	cfg.TabWidth = *flagTabWidth
-	cfg.Simplify = !*flagNoSimplify
-	cfg.Align = tree.PrettyNoAlign
-	cfg.Case = caseModes[*flagCasemode]
-	cfg.JSONFmt = true
-	if *flagAlign {
-		cfg.Align = tree.PrettyAlignAndDeindent
+	// The statements are joined, so only the last gets a final newline.
+	final := opts.FinalNewline
+	opts.FinalNewline = sqlfmt.FinalNewlineNever
+	res := make([]string, 0, len(*flagStmts))
+	var allWarns []sqlfmt.Warning
+	for i, s := range *flagStmts {
+		if i == len(*flagStmts)-1 {
+			opts.FinalNewline = final
+		}
+		r, warns, err := format(s, opts)
+		name := fmt.Sprintf("<stmt %d>", i+1)
+		if err != nil {
+			return errors.New(errorText(name, err))
+		}
+		for _, w := range warns {
+			fmt.Fprintf(os.Stderr, "%s:%v\n", name, w)
+		}
+		allWarns = append(allWarns, warns...)
+		if r != "" {
+			res = append(res, r)
+		}
 	}
+	fmt.Print(strings.Join(res, "\n\n"))
+	return checkWidth(allWarns)
+}
+
+// configs are the config files found by findConfigs, by directory.
+var configs = map[string][]*sqlfmt.Config{}
 
-	res, err := sqlfmt.FmtSQL(cfg, sl)
+// findConfigs returns the config files that apply to the files in dir.
+func findConfigs(dir string) ([]*sqlfmt.Config, error) {
+	if c, ok := configs[dir]; ok {
+		return c, nil
+	}
+	c, err := sqlfmt.FindConfigs(dir)
+	if err != nil {
+		return nil, err
+	}
+	configs[dir] = c
+	return c, nil
+}
+
+// editorConfigs are the EditorConfig files found by findEditorConfigs, by
+// directory.
+var editorConfigs = map[string][]*sqlfmt.EditorConfig{}
+
+// findEditorConfigs returns the EditorConfig files that apply to the files
+// in dir, or none without --editorconfig.
+func findEditorConfigs(dir string) ([]*sqlfmt.EditorConfig, error) {
+	if !*flagEditorCfg {
+		return nil, nil
+	}
+	if ec, ok := editorConfigs[dir]; ok {
+		return ec, nil
+	}
+	ec, err := sqlfmt.FindEditorConfigs(dir)
+	if err != nil {
+		return nil, err
+	}
+	editorConfigs[dir] = ec
+	return ec, nil
+}
+
+// fileOptions returns the options for the file at path, or for stdin and
+// the --stmt arguments if path is "". Those are the options set by the
+// EditorConfig files that apply to a file, changed by the config files
+// that apply to it, changed by the flags.
+func fileOptions(path string) (sqlfmt.Options, error) {
+	opts := sqlfmt.DefaultOptions()
+	dir := "."
+	if path != "" {
+		dir = filepath.Dir(path)
+		ec, err := findEditorConfigs(dir)
+		if err != nil {
+			return opts, err
+		}
+		if err := opts.ApplyEditorConfigs(ec, path); err != nil {
+			return opts, err
+		}
+	}
+	c, err := findConfigs(dir)
+	if err != nil {
+		return opts, err
+	}
+	if err := opts.ApplyConfigs(c, path); err != nil {
+		return opts, err
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if optionFlags[f.Name] && err == nil {
+			if err = opts.Set(f.Name, f.Value.String()); err != nil {
+				err = fmt.Errorf("--%s: %v", f.Name, err)
+			}
+		}
+	})
+	if err != nil {
+		return opts, err
+	}
+	opts.AllErrors = *flagAllErrors
+	opts.Tolerant = *flagTolerant
+	opts.Concurrency = *flagJobs
+	opts.Verify = *flagVerify
+	if err := opts.Validate(); err != nil {
+		if path != "" {
+			err = fmt.Errorf("%s: %v", path, err)
+		}
+		return opts, err
+	}
+	return opts, nil
+}
+
+// runConfig runs the config command, which prints the settings that
+// apply to the file named by --show.
+// isConfigCmd reports whether args run the config command. A file or
+// directory named config is formatted instead, unless --show is given.
+func isConfigCmd(args []string) bool {
+	if len(args) == 0 || args[0] != "config" {
+		return false
+	}
+	if *flagShow != "" {
+		return true
+	}
+	_, err := os.Stat(args[0])
+	return errors.Is(err, os.ErrNotExist)
+}
+
+func runConfig(args []string) error {
+	if len(args) > 0 || *flagShow == "" {
+		return errors.New("usage: sqlfmt config --show FILE")
+	}
+	opts, err := fileOptions(*flagShow)
+	if err != nil {
+		return err
+	}
+	ec, err := findEditorConfigs(filepath.Dir(*flagShow))
 	if err != nil {
 		return err
 	}
-	fmt.Println(res)
+	for _, ec := range ec {
+		fmt.Printf("# %s\n", ec.Path)
+	}
+	c, err := findConfigs(filepath.Dir(*flagShow))
+	if err != nil {
+		return err
+	}
+	for _, c := range c {
+		fmt.Printf("# %s\n", c.Path)
+	}
+	for _, s := range opts.Settings() {
+		v := s.Value
+		if _, err := strconv.Atoi(v); err != nil && v != "true" && v != "false" {
+			v = strconv.Quote(v)
+		}
+		fmt.Printf("%s = %s\n", s.Name, v)
+	}
 	return nil
 }
 
-var (
-	ignoreComments = regexp.MustCompile(`^--.*\s*`)
-)
+// diskCache is the --cache, or nil.
+var diskCache *sqlfmt.DiskCache
+
+// format formats src using opts, reusing the output in the --cache if
+// there is one.
+func format(src string, opts sqlfmt.Options) (string, []sqlfmt.Warning, error) {
+	if diskCache != nil {
+		return diskCache.Format(context.Background(), src, opts)
+	}
+	return sqlfmt.FormatWithWarnings(context.Background(), src, opts)
+}
+
+// checkWidth returns an error if --max-width-strict is set and warns has
+// lines wider than the line width.
+func checkWidth(warns []sqlfmt.Warning) error {
+	if !*flagStrict {
+		return nil
+	}
+	n := 0
+	for _, w := range warns {
+		if w.Overflow != nil {
+			n++
+		}
+	}
+	if n > 0 {
+		return fmt.Errorf("%d lines are wider than --print-width", n)
+	}
+	return nil
+}
+
+// stmtResult is the result of formatting a statement for --output=json.
+type stmtResult struct {
+	// Input is the name of the input, like "<stdin>".
+	Input string
+	// Start and End are the byte span of the statement in Input.
+	Start, End int
+	Text       string
+	Tag        string `json:",omitempty"`
+	Error      string `json:",omitempty"`
+	// Line and Column are the position of a parse error.
+	Line   int `json:",omitempty"`
+	Column int `json:",omitempty"`
+}
+
+// runJSON formats stdin or the --stmt arguments and writes the result of
+// each statement to stdout as JSON.
+func runJSON(opts sqlfmt.Options) error {
+	if *flagStream || *flagLines != "" || *flagOffset != "" {
+		return errors.New("--output=json can't be used with --stream, --lines or --offset")
+	}
+	inputs := *flagStmts
+	name := func(i int) string { return fmt.Sprintf("<stmt %d>", i+1) }
+	if len(inputs) == 0 {
+		in, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		inputs = []string{string(in)}
+		name = func(int) string { return "<stdin>" }
+	}
+	results := []stmtResult{}
+	failed := 0
+	for i, in := range inputs {
+		rs, err := sqlfmt.FormatStatements(context.Background(), in, opts)
+		if err != nil {
+			return errors.New(errorText(name(i), err))
+		}
+		for _, r := range rs {
+			res := stmtResult{
+				Input: name(i),
+				Start: r.Start,
+				End:   r.End,
+				Text:  r.Text,
+				Tag:   r.Tag,
+			}
+			if r.Err != nil {
+				failed++
+				res.Error = r.Err.Error()
+				var pe *sqlfmt.ParseError
+				if errors.As(r.Err, &pe) {
+					res.Line = pe.Line
+					res.Column = pe.Column
+				}
+			}
+			results = append(results, res)
+		}
+	}
+	enc := gojson.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(results); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d statements couldn't be formatted", failed)
+	}
+	return nil
+}
+
+// runRange formats part of stdin and writes all of it to stdout.
+func runRange(opts sqlfmt.Options) error {
+	if len(*flagStmts) > 0 {
+		return errors.New("--lines and --offset can't be used with --stmt")
+	}
+	if *flagLines != "" && *flagOffset != "" {
+		return errors.New("only one of --lines and --offset can be used")
+	}
+	in, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	src := string(in)
+	var start, end int
+	if *flagLines != "" {
+		first, last, err := parseRange(*flagLines)
+		if err != nil {
+			return fmt.Errorf("--lines: %v", err)
+		}
+		start, end, err = sqlfmt.LineRange(src, first, last)
+		if err != nil {
+			return err
+		}
+	} else {
+		start, end, err = parseRange(*flagOffset)
+		if err != nil {
+			return fmt.Errorf("--offset: %v", err)
+		}
+	}
+	e, err := sqlfmt.FormatRange(context.Background(), src, start, end, opts)
+	if err != nil {
+		return errors.New(errorText("<stdin>", err))
+	}
+	fmt.Print(e.Apply(src))
+	return nil
+}
+
+// parseRange parses a range of the form "a:b".
+func parseRange(s string) (a, b int, err error) {
+	as, bs, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected a range like 10:20: %q", s)
+	}
+	if a, err = strconv.Atoi(as); err != nil {
+		return 0, 0, err
+	}
+	if b, err = strconv.Atoi(bs); err != nil {
+		return 0, 0, err
+	}
+	return a, b, nil
+}
+
+// errorText describes err for display. Errors with a position are
+// prefixed by name, and parse errors include an excerpt of the input with a
+// caret at the error position.
+func errorText(name string, err error) string {
+	var pes sqlfmt.ParseErrors
+	if errors.As(err, &pes) {
+		texts := make([]string, len(pes))
+		for i, pe := range pes {
+			texts[i] = errorText(name, pe)
+		}
+		return strings.Join(texts, "\n")
+	}
+	var ve *sqlfmt.VerifyError
+	if errors.As(err, &ve) && name != "" {
+		return fmt.Sprintf("%s:%v", name, ve)
+	}
+	var de *sqlfmt.DirectiveError
+	if errors.As(err, &de) && name != "" {
+		return fmt.Sprintf("%s:%v", name, de)
+	}
+	var pe *sqlfmt.ParseError
+	if !errors.As(err, &pe) {
+		return err.Error()
+	}
+	var sb strings.Builder
+	if name != "" {
+		fmt.Fprintf(&sb, "%s:", name)
+	}
+	fmt.Fprintf(&sb, "%v\n%s", pe, pe.Snippet())
+	if pe.Hint != "" {
+		fmt.Fprintf(&sb, "\nHINT: %s", pe.Hint)
+	}
+	if pe.Detail != "" {
+		fmt.Fprintf(&sb, "\nDETAIL: %s", pe.Detail)
+	}
+	return sb.String()
+}
 
 func serveHTTP(spec Specification) {
 	fmt.Printf("SPEC: %#v\n", spec)
@@ -162,6 +593,12 @@ func serveHTTP(spec Specification) {
 			http.Error(w, err.Error(), 500)
 		}
 	})
+	budget.timeout = spec.Timeout
+	budget.maxInput = spec.MaxInput
+	if spec.MaxFormats <= 0 {
+		spec.MaxFormats = runtime.NumCPU()
+	}
+	budget.formats = make(chan struct{}, spec.MaxFormats)
 	mux.HandleFunc("/fmt", wrap(Fmt))
 	srv := &http.Server{
 		Addr:           spec.Addr,
@@ -203,9 +640,15 @@ func wrap(f func(http.ResponseWriter, *http.Request) fmtResponse) http.HandlerFu
 		res := f(w, r)
 		if r.FormValue("json") == "" {
 			w.Header().Add("Content-Type", "text/plain")
+			if res.status != 0 {
+				w.WriteHeader(res.status)
+			}
 			w.Write([]byte(res.Data))
 		} else {
 			w.Header().Add("Content-Type", "application/json")
+			if res.status != 0 {
+				w.WriteHeader(res.status)
+			}
 			if err := gojson.NewEncoder(w).Encode(res); err != nil {
 				log.Print(err)
 			}
@@ -216,15 +659,30 @@ func wrap(f func(http.ResponseWriter, *http.Request) fmtResponse) http.HandlerFu
 type fmtResponse struct {
 	Data  string
 	Error bool
+	// Line and Column are the position of a parse error.
+	Line   int `json:",omitempty"`
+	Column int `json:",omitempty"`
+	// Width is the display width of the widest line of Data.
+	Width int `json:",omitempty"`
+	// status is the HTTP status of the response if it isn't 200 OK.
+	status int
 }
 
-var cache = struct {
-	sync.RWMutex
-	m map[string]fmtResponse
-}{
-	m: make(map[string]fmtResponse),
+// cache holds the statements formatted by requests, so statements that are
+// formatted again, like those of a query being edited, aren't parsed again.
+var cache = sqlfmt.NewCache(10000)
+
+// budget limits the formatting done by each request. formats holds a
+// value for each request being formatted.
+var budget struct {
+	timeout  time.Duration
+	maxInput int
+	formats  chan struct{}
 }
 
+// errBusy is returned when too many requests are being formatted.
+var errBusy = errors.New("too many requests are being formatted, try again later")
+
 func parseBool(val string) (bool, error) {
 	switch val {
 	case "on":
@@ -237,29 +695,25 @@ func parseBool(val string) (bool, error) {
 }
 
 func Fmt(w http.ResponseWriter, r *http.Request) fmtResponse {
-	cache.RLock()
-	hit, ok := cache.m[r.URL.RawQuery]
-	cache.RUnlock()
-	if ok {
-		return hit
-	}
-
 	res, err := fmtSQLRequest(r)
 	response := fmtResponse{
 		Data:  res,
 		Error: err != nil,
 	}
 	if err != nil {
-		response.Data = err.Error()
-	}
-	cache.Lock()
-	if len(cache.m) > 10000 {
-		for k := range cache.m {
-			delete(cache.m, k)
+		response.Data = errorText("", err)
+		if errors.Is(err, errBusy) {
+			response.status = http.StatusServiceUnavailable
 		}
+		var pe *sqlfmt.ParseError
+		if errors.As(err, &pe) {
+			response.Line = pe.Line
+			response.Column = pe.Column
+		}
+	} else {
+		tabWidth, _ := strconv.Atoi(r.FormValue("indent"))
+		response.Width = sqlfmt.DisplayWidth(res, tabWidth)
 	}
-	cache.m[r.URL.RawQuery] = response
-	cache.Unlock()
 	return response
 }
 
@@ -287,50 +741,60 @@ func fmtSQLRequest(r *http.Request) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	casemode := caseModes[r.FormValue("case")]
 	spaces, err := parseBool(r.FormValue("spaces"))
 	if err != nil {
 		return "", err
 	}
 
-	pcfg := tree.DefaultPrettyCfg()
-	pcfg.LineWidth = n
-	pcfg.UseTabs = !spaces
-	pcfg.TabWidth = tabWidth
-	pcfg.Simplify = simplify
-	pcfg.Align = tree.PrettyAlignMode(align)
-	pcfg.Case = casemode
-	pcfg.JSONFmt = true
+	opts := sqlfmt.DefaultOptions()
+	opts.LineWidth = n
+	opts.UseTabs = !spaces
+	opts.TabWidth = tabWidth
+	opts.Simplify = simplify
+	opts.FinalNewline = sqlfmt.FinalNewlineNever
+	if align < 0 || align >= len(alignModes) {
+		return "", fmt.Errorf("unknown align mode: %d", align)
+	}
+	opts.Align = alignModes[align]
+	opts.Case = sqlfmt.CaseMode(r.FormValue("case"))
+	opts.Timeout = budget.timeout
+	opts.MaxInputSize = budget.maxInput
+	opts.Cache = cache
+	// Requests are formatted concurrently with each other, so each uses a
+	// single goroutine.
+	opts.Concurrency = 1
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
 
-	res, err := sqlfmt.FmtSQL(pcfg, []string{sql})
+	select {
+	case budget.formats <- struct{}{}:
+	default:
+		return "", errBusy
+	}
+	res, err := sqlfmt.Format(r.Context(), sql, opts)
+	<-budget.formats
 	if err == nil {
 		return res, nil
 	}
+	var le *sqlfmt.LimitError
+	if errors.As(err, &le) {
+		return "", err
+	}
 	if jsonDoc, jErr := sqlfmt.FmtJSON(sql); jErr == nil && jsonDoc != nil {
-		resJSON := pretty.Pretty(jsonDoc, pcfg.LineWidth, pcfg.UseTabs, pcfg.TabWidth, nil)
+		resJSON := pretty.Pretty(jsonDoc, opts.LineWidth, opts.UseTabs, opts.TabWidth, nil)
 		return resJSON, nil
 	}
 	return res, err
 }
 
-var caseModes = map[string]func(string) string{
-	"upper":     strings.ToUpper,
-	"lower":     strings.ToLower,
-	"title":     titleCase,
-	"spongebob": spongeBobCase,
-}
-
-func titleCase(s string) string {
-	return strings.Title(strings.ToLower(s))
-}
-
-func spongeBobCase(s string) string {
-	var b strings.Builder
-	b.Grow(len(s))
-	for _, c := range s {
-		b.WriteRune(unicode.To(rand.Intn(2), c))
-	}
-	return b.String()
+// alignModes are the alignment modes indexed by the value of the align
+// form field.
+var alignModes = []sqlfmt.AlignMode{
+	sqlfmt.AlignNone,
+	sqlfmt.AlignPartial,
+	sqlfmt.AlignFull,
+	sqlfmt.AlignOther,
 }
 
 const (
@@ -467,6 +931,8 @@ Its purpose is to beautifully format SQL statements.
 
 <p>There is a box in which to paste or type SQL statements. Multiple statements are supported by separating them with a semicolon (<code>;</code>). The slider below the box controls the desired maximum line width in characters. Various options on the side control tab/indentation width, the use of spaces or tabs, simplification, and alignment modes. Simplification causes the formatter to remove unneeded parentheses and words when the meaning will be the same without them.</p>
 
+<p>Statements can be left exactly as written by putting them between <code>-- sqlfmt: off</code> and <code>-- sqlfmt: on</code> comments, on their own lines or after the semicolon of the statement before. Options can be changed for a single statement with a comment before it like <code>-- sqlfmt: width=120 case=lower align=partial</code>.</p>
+
 <p>There are four alignment modes. The default, <code>no</code>, uses left alignment. <code>partial</code> right aligns keywords at the width of the longest keyword at the beginning of all lines immediately below. <code>full</code> is the same as <code>partial</code> but the keywords <code>AND</code> and <code>OR</code> are deindented, in a style similar to the sqlite tests. <code>other</code> is like <code>partial</code> but instead of deindenting <code>AND</code> and <code>OR</code>, their arguments are instead indented.</p>
 
 <h3>no:</h3>
@@ -676,8 +1142,7 @@ function range() {
 					actualBytes.innerText = '';
 				} else {
 					fmtText = data.Data
-					tabSpaces = " ".repeat(viw);
-					actualWidth.innerText = Math.max(...fmtText.split('\n').map(v => v.replace(/\t/g, tabSpaces).length));
+					actualWidth.innerText = data.Width;
 					actualBytes.innerText = fmtText.length;
 					hLine = "--";
 					if (v > 2) {
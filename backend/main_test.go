@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFmtBusy(t *testing.T) {
+	budget.formats = make(chan struct{}, 1)
+	defer func() { budget.formats = nil }()
+	form := url.Values{
+		"sql": {"select 1"}, "n": {"60"}, "indent": {"4"}, "simplify": {"true"},
+		"align": {"0"}, "spaces": {"false"}, "case": {"upper"},
+	}
+	fmtReq := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		wrap(Fmt)(w, httptest.NewRequest("GET", "/fmt?"+form.Encode(), nil))
+		return w
+	}
+	if w := fmtReq(); w.Code != http.StatusOK || w.Body.String() != "SELECT 1;" {
+		t.Fatalf("got %d %q, want 200 %q", w.Code, w.Body, "SELECT 1;")
+	}
+	budget.formats <- struct{}{}
+	if w := fmtReq(); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got %d %q while busy, want 503", w.Code, w.Body)
+	}
+}
+
+func TestIsConfigCmd(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	args := []string{"config"}
+	if !isConfigCmd(args) {
+		t.Error("config isn't the config command")
+	}
+	if isConfigCmd([]string{"a.sql", "config"}) {
+		t.Error("config after a file is the config command")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config"), nil, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if isConfigCmd(args) {
+		t.Error("the file named config is the config command")
+	}
+	*flagShow = "a.sql"
+	defer func() { *flagShow = "" }()
+	if !isConfigCmd(args) {
+		t.Error("config with --show isn't the config command")
+	}
+}
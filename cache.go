@@ -0,0 +1,94 @@
+package sqlfmt
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+)
+
+// Cache holds formatted statements so formatting them again with the same
+// options doesn't parse them again. When it is full, the least recently
+// used statement is dropped. A Cache can be shared by concurrent calls.
+type Cache struct {
+	size int
+	mu   sync.Mutex
+	// order has the most recently used entry at the front.
+	order   *list.List
+	entries map[cacheKey]*list.Element
+}
+
+// NewCache returns a Cache holding up to size statements.
+func NewCache(size int) *Cache {
+	return &Cache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[cacheKey]*list.Element),
+	}
+}
+
+// cacheKey identifies a statement and the options it was formatted with.
+type cacheKey struct {
+	stmt, opts [sha256.Size]byte
+}
+
+// cacheEntry is a formatted statement.
+type cacheEntry struct {
+	key    cacheKey
+	tag    string
+	pretty []string
+}
+
+// newCacheKey returns the key for sql formatted using o.
+func newCacheKey(sql string, o Options) cacheKey {
+	// Only the options that change how a single statement is formatted
+	// are included.
+	opts := fmt.Sprintf("%d %d %t %t %s %s %t %t", o.LineWidth, o.TabWidth, o.UseTabs, o.Simplify, o.Align, o.Case, o.JSONFmt, o.Verify)
+	return cacheKey{
+		stmt: sha256.Sum256([]byte(sql)),
+		opts: sha256.Sum256([]byte(opts)),
+	}
+}
+
+// Len returns the number of statements in c.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// get returns the entry for key. c may be nil.
+func (c *Cache) get(key cacheKey) (cacheEntry, bool) {
+	if c == nil {
+		return cacheEntry{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(cacheEntry), true
+}
+
+// add adds e to c, dropping the least recently used entry if c is full. c
+// may be nil.
+func (c *Cache) add(e cacheEntry) {
+	if c == nil || c.size < 1 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[e.key]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[e.key] = c.order.PushFront(e)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(cacheEntry).key)
+	}
+}
@@ -0,0 +1,115 @@
+package sqlfmt
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCacheEviction(t *testing.T) {
+	opts := DefaultOptions()
+	key := func(sql string) cacheKey { return newCacheKey(sql, opts) }
+	c := NewCache(2)
+	// Each step adds or gets a statement, then checks which are cached.
+	tests := []struct {
+		add, get string
+		cached   []string
+		missing  []string
+	}{
+		{add: "a", cached: []string{"a"}},
+		{add: "b", cached: []string{"a", "b"}},
+		// Getting a makes b the least recently used.
+		{get: "a"},
+		{add: "c", cached: []string{"a", "c"}, missing: []string{"b"}},
+		// Adding a again updates it and makes c the least recently used.
+		{add: "a"},
+		{add: "d", cached: []string{"a", "d"}, missing: []string{"c"}},
+	}
+	for i, tc := range tests {
+		if tc.add != "" {
+			c.add(cacheEntry{key: key(tc.add), tag: tc.add})
+		}
+		if tc.get != "" {
+			if _, ok := c.get(key(tc.get)); !ok {
+				t.Fatalf("%d: %s not cached", i, tc.get)
+			}
+		}
+		if n := c.Len(); n > 2 {
+			t.Fatalf("%d: cache has %d statements, want at most 2", i, n)
+		}
+		// Checking with get would change the order, so look at entries.
+		for _, s := range tc.cached {
+			if el, ok := c.entries[key(s)]; !ok || el.Value.(cacheEntry).tag != s {
+				t.Errorf("%d: %s not cached", i, s)
+			}
+		}
+		for _, s := range tc.missing {
+			if _, ok := c.entries[key(s)]; ok {
+				t.Errorf("%d: %s still cached", i, s)
+			}
+		}
+	}
+	var nilCache *Cache
+	nilCache.add(cacheEntry{key: key("a")})
+	if _, ok := nilCache.get(key("a")); ok {
+		t.Error("nil cache has an entry")
+	}
+	empty := NewCache(0)
+	empty.add(cacheEntry{key: key("a")})
+	if empty.Len() != 0 {
+		t.Error("cache of size 0 has an entry")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	const sql = "select a, b from t"
+	base := newCacheKey(sql, DefaultOptions())
+	tests := []struct {
+		name string
+		opts func(*Options)
+		same bool
+	}{
+		{"line width", func(o *Options) { o.LineWidth = 100 }, false},
+		{"tab width", func(o *Options) { o.TabWidth = 8 }, false},
+		{"use tabs", func(o *Options) { o.UseTabs = !o.UseTabs }, false},
+		{"simplify", func(o *Options) { o.Simplify = !o.Simplify }, false},
+		{"align", func(o *Options) { o.Align = AlignPartial }, false},
+		{"case", func(o *Options) { o.Case = CaseLower }, false},
+		{"json", func(o *Options) { o.JSONFmt = !o.JSONFmt }, false},
+		{"verify", func(o *Options) { o.Verify = !o.Verify }, false},
+		// Options that don't change single statements share entries.
+		{"line ending", func(o *Options) { o.LineEnding = LineEndingCRLF }, true},
+		{"max blank lines", func(o *Options) { o.MaxBlankLines = 3 }, true},
+		{"tolerant", func(o *Options) { o.Tolerant = true }, true},
+	}
+	for _, tc := range tests {
+		opts := DefaultOptions()
+		tc.opts(&opts)
+		if got := newCacheKey(sql, opts) == base; got != tc.same {
+			t.Errorf("%s: got same key %v, want %v", tc.name, got, tc.same)
+		}
+	}
+	if newCacheKey(sql+" ", DefaultOptions()) == base {
+		t.Error("different statements have the same key")
+	}
+
+	// Formatting with a shared cache gives the output for each options.
+	cache := NewCache(10)
+	for _, tc := range tests {
+		opts := DefaultOptions()
+		tc.opts(&opts)
+		want, err := Format(context.Background(), sql, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts.Cache = cache
+		for i := 0; i < 2; i++ {
+			got, err := Format(context.Background(), sql, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("%s: got %q from the cache, want %q", tc.name, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package sqlfmt
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/lexbase"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/scanner"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+)
+
+// token is a lexical token and its byte offsets in the scanned string.
+type token struct {
+	id         int32
+	str        string
+	start, end int
+}
+
+// tokenSym implements scanner.ScanSymType so we can record the position
+// of each token.
+type tokenSym struct {
+	id    int32
+	pos   int32
+	str   string
+	union interface{}
+}
+
+func (s *tokenSym) ID() int32                 { return s.id }
+func (s *tokenSym) SetID(id int32)            { s.id = id }
+func (s *tokenSym) Pos() int32                { return s.pos }
+func (s *tokenSym) SetPos(p int32)            { s.pos = p }
+func (s *tokenSym) Str() string               { return s.str }
+func (s *tokenSym) SetStr(v string)           { s.str = v }
+func (s *tokenSym) UnionVal() interface{}     { return s.union }
+func (s *tokenSym) SetUnionVal(v interface{}) { s.union = v }
+
+// tokenize returns the tokens of sql. Scanning stops at the first lexical
+// error.
+func tokenize(sql string) []token {
+	var s scanner.Scanner
+	s.Init(sql)
+	defer s.Cleanup()
+	var toks []token
+	for {
+		var lval tokenSym
+		s.Scan(&lval)
+		if lval.id == 0 || lval.id == lexbase.ERROR {
+			return toks
+		}
+		// The scanner is past the character after quoted tokens, so
+		// trim it if it's a space.
+		start := int(lval.pos)
+		end := start + len(strings.TrimRight(sql[start:s.Pos()], " \t\r\n\f\v"))
+		toks = append(toks, token{
+			id:    lval.id,
+			str:   lval.str,
+			start: start,
+			end:   end,
+		})
+	}
+}
+
+// comment is a comment found between the tokens of a statement.
+type comment struct {
+	text string
+	// prev is the index of the token before the comment, or -1.
+	prev int
+	// trailing is set if the comment is on the same line as prev.
+	trailing bool
+}
+
+// commentLen returns the length of the comment at the start of s, or 0
+// if s does not start with a comment. Line comments do not include their
+// terminating newline or CRLF. Block comments may be nested. ok is false if
+// s starts with an unterminated block comment.
+func commentLen(s string) (n int, ok bool) {
+	if strings.HasPrefix(s, "--") {
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			return len(strings.TrimSuffix(s[:i], "\r")), true
+		}
+		return len(s), true
+	}
+	if !strings.HasPrefix(s, "/*") {
+		return 0, true
+	}
+	depth := 0
+	for i := 0; i < len(s)-1; i++ {
+		switch s[i : i+2] {
+		case "/*":
+			depth++
+			i++
+		case "*/":
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1, true
+			}
+		}
+	}
+	return len(s), false
+}
+
+// trailingCommentLen returns the length of the prefix of s up to the end
+// of a comment starting on the first line of s, or 0 if there is none.
+func trailingCommentLen(s string) int {
+	i := 0
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	if n, ok := commentLen(s[i:]); n > 0 && ok {
+		return i + n
+	}
+	return 0
+}
+
+// directivePrefix starts comments that control formatting.
+const directivePrefix = "sqlfmt:"
+
+// directive returns the text after "sqlfmt:" if comment is a directive.
+func directive(comment string) (string, bool) {
+	s := comment
+	if strings.HasPrefix(s, "--") {
+		s = s[2:]
+	} else {
+		s = strings.TrimSuffix(strings.TrimPrefix(s, "/*"), "*/")
+	}
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, directivePrefix) {
+		return "", false
+	}
+	return strings.TrimSpace(s[len(directivePrefix):]), true
+}
+
+// findComments returns the comments in sql, which was split into toks.
+func findComments(sql string, toks []token) []comment {
+	var comments []comment
+	for prev := -1; prev < len(toks); prev++ {
+		start, end := 0, len(sql)
+		if prev >= 0 {
+			start = toks[prev].end
+		}
+		if prev+1 < len(toks) {
+			end = toks[prev+1].start
+		}
+		gap := sql[start:end]
+		newline := false
+		for i := 0; i < len(gap); {
+			if n, _ := commentLen(gap[i:]); n > 0 {
+				comments = append(comments, comment{
+					text:     gap[i : i+n],
+					prev:     prev,
+					trailing: prev >= 0 && !newline,
+				})
+				i += n
+				continue
+			}
+			if gap[i] == '\n' {
+				newline = true
+			}
+			i++
+		}
+	}
+	return comments
+}
+
+// alignLookahead is how far ahead alignTokens searches for a matching
+// token. Formatting mostly preserves token order, only adding or removing
+// a few tokens (parentheses, AS, type names) at a time.
+const alignLookahead = 8
+
+// alignTokens maps each token in a to the index of the equivalent token in
+// b, or -1 if it has none. When the tokens differ, it skips the fewest
+// tokens of a or b needed to make them match, preferring skips after which
+// the following tokens match too.
+func alignTokens(a, b []token) []int {
+	m := make([]int, len(a))
+	for i := range m {
+		m[i] = -1
+	}
+	same := func(i, j int) bool {
+		if i >= len(a) || j >= len(b) {
+			// Past the end of either, so there is nothing to disagree.
+			return true
+		}
+		return a[i].id == b[j].id && a[i].str == b[j].str
+	}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		if same(i, j) {
+			m[i] = j
+			i++
+			j++
+			continue
+		}
+		skipped := false
+		for d := 1; d <= alignLookahead && !skipped; d++ {
+			// Formatting removes tokens (parentheses) more often than it
+			// adds them, so prefer skipping tokens of a.
+			inA, inB := i+d < len(a) && same(i+d, j), j+d < len(b) && same(i, j+d)
+			switch {
+			case inB && same(i+1, j+d+1):
+				j += d
+			case inA && same(i+d+1, j+1):
+				i += d
+			case inA:
+				i += d
+			case inB:
+				j += d
+			default:
+				continue
+			}
+			skipped = true
+		}
+		if !skipped {
+			// The token was replaced.
+			i++
+			j++
+		}
+	}
+	return m
+}
+
+// insertion is text to be inserted into formatted output, replacing the
+// skip bytes at pos.
+type insertion struct {
+	pos  int
+	text string
+	skip int
+}
+
+// prettyWithComments pretty prints stmt, which was parsed from sql, and
+// places the comments in sql near the tokens they were next to in sql.
+// The result includes the terminating semicolon.
+func prettyWithComments(cfg tree.PrettyCfg, sql string, stmt tree.Statement) string {
+	inToks := tokenize(sql)
+	comments := findComments(sql, inToks)
+	if len(comments) == 0 {
+		return cfg.Pretty(stmt) + ";"
+	}
+	// Comments can only be placed next to their tokens if those tokens are
+	// at the start or end of a line, so narrow the width a little until
+	// they are. Narrowing further would reflow the parts of the statement
+	// without comments, so below minWidth the lines are broken after the
+	// comments instead.
+	minWidth := cfg.LineWidth * 3 / 4
+	indent := "\t"
+	if !cfg.UseTabs {
+		indent = strings.Repeat(" ", cfg.TabWidth)
+	}
+	var best string
+	bestConflicts := -1
+	for width := cfg.LineWidth; width >= minWidth && width > 0; width -= width/8 + 1 {
+		c := cfg
+		c.LineWidth = width
+		res, conflicts := placeComments(c.Pretty(stmt)+";", inToks, comments, indent)
+		if bestConflicts < 0 || conflicts < bestConflicts {
+			best, bestConflicts = res, conflicts
+		}
+		if conflicts == 0 {
+			break
+		}
+	}
+	return best
+}
+
+// placeComments inserts comments into out, the formatted version of the
+// statement that was split into inToks. It returns the number of comments
+// that could not be placed on their own line or at the end of their line
+// as they were in the input. A line comment that was at the end of its line
+// but is followed by other tokens in out ends the line, and the rest of it
+// continues on the next line, indented by indent. Other comments are placed
+// on their own line above the line of the token they were next to.
+func placeComments(out string, inToks []token, comments []comment, indent string) (string, int) {
+	outToks := tokenize(out)
+	m := alignTokens(inToks, outToks)
+	conflicts := 0
+	// trailingLines is the set of line starts with a line comment at their
+	// end.
+	trailingLines := map[int]bool{}
+
+	var ins []insertion
+	for _, c := range comments {
+		if c.trailing {
+			// Attach to the nearest preceding token that survived formatting.
+			p := c.prev
+			for p >= 0 && m[p] < 0 {
+				p--
+			}
+			if p >= 0 {
+				t := outToks[m[p]]
+				pos := t.end
+				if strings.HasPrefix(c.text, "--") {
+					pos = lineEnd(out, pos)
+					line := lineStart(out, pos)
+					// The comment ended its line in the input, so the next
+					// token must not have been moved up onto this line.
+					n := c.prev + 1
+					for n < len(inToks) && m[n] < 0 {
+						n++
+					}
+					movedUp := n < len(inToks) && !isFinalSemicolon(inToks, n) &&
+						lineStart(out, outToks[m[n]].start) == line
+					// Nothing can be moved after the final semicolon, so
+					// comments there never conflict.
+					rest := out[t.end:pos]
+					if !isFinalSemicolon(inToks, p) && (trailingLines[line] || movedUp) && strings.TrimSpace(rest) != "" {
+						// Ending the line here would comment out the rest
+						// of it, so break the line after the token.
+						conflicts++
+						space := len(rest) - len(strings.TrimLeft(rest, " \t"))
+						ins = append(ins, insertion{t.end, " " + c.text + "\n" + lineIndent(out, line) + indent, space})
+						continue
+					}
+					trailingLines[line] = true
+				}
+				ins = append(ins, insertion{pos: pos, text: " " + c.text})
+				continue
+			}
+		}
+		// Place the comment on its own line above the next token that
+		// survived formatting, or at the end if there isn't one.
+		n := c.prev + 1
+		for n < len(inToks) && m[n] < 0 {
+			n++
+		}
+		if n == len(inToks) || isFinalSemicolon(inToks, n) {
+			ins = append(ins, insertion{pos: len(out), text: "\n" + c.text})
+			continue
+		}
+		t := outToks[m[n]]
+		pos := lineStart(out, t.start)
+		lead := lineIndent(out, pos)
+		if len(lead) != t.start-pos {
+			conflicts++
+		}
+		ins = append(ins, insertion{pos: pos, text: lead + c.text + "\n"})
+	}
+	sort.SliceStable(ins, func(i, j int) bool { return ins[i].pos < ins[j].pos })
+	var sb strings.Builder
+	prev := 0
+	for _, in := range ins {
+		if in.pos < prev {
+			in.pos = prev
+		}
+		sb.WriteString(out[prev:in.pos])
+		sb.WriteString(in.text)
+		prev = in.pos + in.skip
+	}
+	sb.WriteString(out[prev:])
+	return sb.String(), conflicts
+}
+
+// isFinalSemicolon reports whether toks[i] is the semicolon ending the
+// statement. The formatter always places it at the end of the last line.
+func isFinalSemicolon(toks []token, i int) bool {
+	return i == len(toks)-1 && toks[i].id == ';'
+}
+
+// lineStart returns the offset of the start of the line containing pos.
+func lineStart(s string, pos int) int {
+	return strings.LastIndexByte(s[:pos], '\n') + 1
+}
+
+// lineIndent returns the indentation of the line starting at pos.
+func lineIndent(s string, pos int) string {
+	rest := s[pos:]
+	return rest[:len(rest)-len(strings.TrimLeft(rest, " \t"))]
+}
+
+// lineEnd returns the offset of the end of the line containing pos.
+func lineEnd(s string, pos int) int {
+	if i := strings.IndexByte(s[pos:], '\n'); i >= 0 {
+		return pos + i
+	}
+	return len(s)
+}
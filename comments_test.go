@@ -0,0 +1,107 @@
+package sqlfmt
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCommentsSurvive(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		comment string
+	}{
+		{"select leading", "-- lead\nselect a, b from t;", "-- lead"},
+		{"select trailing", "select a, b from t; -- trail", "-- trail"},
+		{"select inline", "select a, /* inline */ b from t;", "/* inline */"},
+		{"select own line", "select a,\n-- own line\nb from t;", "-- own line"},
+		{"insert leading", "-- lead\ninsert into t values (1, 2);", "-- lead"},
+		{"insert trailing", "insert into t values (1, 2); -- trail", "-- trail"},
+		{"insert inline", "insert into t (a, /* inline */ b) values (1, 2);", "/* inline */"},
+		{"insert own line", "insert into t\n-- own line\nvalues (1, 2);", "-- own line"},
+		{"update leading", "-- lead\nupdate t set a = 1 where b = 2;", "-- lead"},
+		{"update trailing", "update t set a = 1 where b = 2; -- trail", "-- trail"},
+		{"update inline", "update t set a = /* inline */ 1 where b = 2;", "/* inline */"},
+		{"update own line", "update t set a = 1\n-- own line\nwhere b = 2;", "-- own line"},
+		{"delete leading", "-- lead\ndelete from t where a = 1;", "-- lead"},
+		{"delete trailing", "delete from t where a = 1; -- trail", "-- trail"},
+		{"delete inline", "delete from t where /* inline */ a = 1;", "/* inline */"},
+		{"delete own line", "delete from t\n-- own line\nwhere a = 1;", "-- own line"},
+		{"create table leading", "-- lead\ncreate table t (a int, b string);", "-- lead"},
+		{"create table trailing", "create table t (a int, b string); -- trail", "-- trail"},
+		{"create table inline", "create table t (a int /* inline */, b string);", "/* inline */"},
+		{"create table own line", "create table t (\na int,\n-- own line\nb string\n);", "-- own line"},
+		{"create table column", "create table t (\na int, -- column\nb string\n);", "-- column"},
+	}
+	ctx := context.Background()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := Format(ctx, tc.src, DefaultOptions())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out, tc.comment) {
+				t.Fatalf("comment %q missing from output:\n%s", tc.comment, out)
+			}
+			again, err := Format(ctx, out, DefaultOptions())
+			if err != nil {
+				t.Fatalf("formatting output: %v\n%s", err, out)
+			}
+			if again != out {
+				t.Fatalf("formatting isn't idempotent:\n%s\nformatted again:\n%s", out, again)
+			}
+		})
+	}
+}
+
+func TestCommentLayout(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{
+			// Statements aren't narrowed much to keep a comment at the end
+			// of its line, so the line is broken after the comment instead.
+			src: "select a, -- first col\nb from t where x = 1 and y = 2;",
+			want: `SELECT a, -- first col
+	b FROM t WHERE x = 1 AND y = 2;
+`,
+		},
+		{
+			src: "select a, -- c1\n b, -- c2\n c from t;",
+			want: `SELECT a, -- c1
+	b, -- c2
+	c FROM t;
+`,
+		},
+		{
+			src: "create table t (id int primary key, -- pk\nname string);",
+			want: `CREATE TABLE t (id INT8 PRIMARY KEY, -- pk
+	name STRING);
+`,
+		},
+		{
+			src: "create table accounts (id int primary key, -- pk\nowner_name string not null, -- who\nbalance decimal, created timestamp);",
+			want: `CREATE TABLE accounts (
+	id
+		INT8 PRIMARY KEY, -- pk
+	owner_name
+		STRING NOT NULL, -- who
+	balance
+		DECIMAL,
+	created
+		TIMESTAMP
+);
+`,
+		},
+	}
+	for _, tc := range tests {
+		out, err := Format(context.Background(), tc.src, DefaultOptions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != tc.want {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", tc.src, out, tc.want)
+		}
+	}
+}
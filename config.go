@@ -0,0 +1,352 @@
+package sqlfmt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConfigFileName is the name of sqlfmt config files. A config file applies
+// to the files in its directory and the directories below it. It sets
+// options by the names accepted by Options.Set, and can change them for
+// some files in [[override]] tables:
+//
+//	print-width = 80
+//	use-spaces = true
+//
+//	[[override]]
+//	files = "migrations/**"
+//	print-width = 100
+//
+// Patterns are matched against paths relative to the config file's
+// directory, with "**" matching any number of directories. Patterns without
+// a slash match file names in any directory. A config file with
+// "root = true" stops the search for config files in the directories above
+// it.
+const ConfigFileName = ".sqlfmt.toml"
+
+// Config is a config file.
+type Config struct {
+	// Path is the path of the config file.
+	Path string
+	// Root is set if config files above this one are ignored.
+	Root bool
+	// Settings are the options set for all files, in order.
+	Settings []Setting
+	// Overrides are the options set for some files, in order.
+	Overrides []Override
+}
+
+// Setting is an option set by a config file.
+type Setting struct {
+	// Name and Value are the arguments to Options.Set.
+	Name, Value string
+	// Line is the 1-based line of the config file where it is set.
+	Line int
+}
+
+// Override is an [[override]] table of a config file.
+type Override struct {
+	// Files are the patterns of the files it applies to.
+	Files    []string
+	Settings []Setting
+}
+
+// ReadConfig reads the config file at path.
+func ReadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseConfig(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s:%v", path, err)
+	}
+	c.Path = path
+	return c, nil
+}
+
+// FindConfigs returns the config files that apply to the files in dir,
+// starting with the one furthest up.
+func FindConfigs(dir string) ([]*Config, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var configs []*Config
+	for {
+		c, err := ReadConfig(filepath.Join(dir, ConfigFileName))
+		if err == nil {
+			configs = append([]*Config{c}, configs...)
+			if c.Root {
+				break
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return configs, nil
+}
+
+// ApplyConfigs sets the options in configs, which are ordered like the
+// result of FindConfigs, for the file at path. Overrides are skipped if
+// path is "".
+func (o *Options) ApplyConfigs(configs []*Config, path string) error {
+	abs := ""
+	if path != "" {
+		var err error
+		if abs, err = filepath.Abs(path); err != nil {
+			return err
+		}
+	}
+	for _, c := range configs {
+		if err := o.applySettings(c, c.Settings); err != nil {
+			return err
+		}
+		if abs == "" {
+			continue
+		}
+		for _, ov := range c.Overrides {
+			if ov.matches(filepath.Dir(c.Path), abs) {
+				if err := o.applySettings(c, ov.Settings); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// applySettings sets settings, which are from c.
+func (o *Options) applySettings(c *Config, settings []Setting) error {
+	for _, s := range settings {
+		if err := o.Set(s.Name, s.Value); err != nil {
+			return fmt.Errorf("%s:%d: %s: %v", c.Path, s.Line, s.Name, err)
+		}
+	}
+	return nil
+}
+
+// matches reports whether ov applies to the file at path, which is
+// absolute, for a config file in dir.
+func (ov Override) matches(dir, path string) bool {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	name := strings.Split(filepath.ToSlash(rel), "/")
+	for _, p := range ov.Files {
+		pat := strings.Split(p, "/")
+		if !strings.Contains(p, "/") {
+			// Match the file name in any directory.
+			pat = []string{"**", p}
+		}
+		if matchGlob(pat, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob reports whether the path split into name matches the pattern
+// split into pat. "**" matches any number of elements.
+func matchGlob(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlob(pat[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// parseConfig parses a config file, which uses the subset of TOML needed
+// by sqlfmt: key/value pairs of strings, integers and booleans, arrays of
+// strings, and [[override]] tables.
+func parseConfig(data string) (*Config, error) {
+	c := &Config{}
+	// ov is the current [[override]] table, or nil at the top level.
+	var ov *Override
+	for i, line := range strings.Split(data, "\n") {
+		lineNum := i + 1
+		line = strings.TrimSpace(stripTOMLComment(line))
+		if line == "" {
+			continue
+		}
+		if line == "[[override]]" {
+			c.Overrides = append(c.Overrides, Override{})
+			ov = &c.Overrides[len(c.Overrides)-1]
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("%d: unknown table: %s", lineNum, line)
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%d: expected key = value: %s", lineNum, line)
+		}
+		key = strings.TrimSpace(key)
+		if k, err := strconv.Unquote(key); err == nil {
+			key = k
+		}
+		values, array, err := parseTOMLValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%d: %s: %v", lineNum, key, err)
+		}
+		switch {
+		case key == "root" && ov == nil:
+			if !array {
+				c.Root, err = strconv.ParseBool(values[0])
+			}
+			if array || err != nil {
+				return nil, fmt.Errorf("%d: root must be a boolean", lineNum)
+			}
+		case key == "files" && ov != nil:
+			for _, p := range values {
+				if _, err := path.Match(p, ""); err != nil {
+					return nil, fmt.Errorf("%d: files: %v: %s", lineNum, err, p)
+				}
+			}
+			ov.Files = append(ov.Files, values...)
+		default:
+			if array {
+				return nil, fmt.Errorf("%d: %s can't be an array", lineNum, key)
+			}
+			// Check the setting now, so errors are reported even if it
+			// isn't used.
+			var o Options
+			if err := o.Set(key, values[0]); err != nil {
+				return nil, fmt.Errorf("%d: %s: %v", lineNum, key, err)
+			}
+			s := Setting{Name: key, Value: values[0], Line: lineNum}
+			if ov != nil {
+				ov.Settings = append(ov.Settings, s)
+			} else {
+				c.Settings = append(c.Settings, s)
+			}
+		}
+	}
+	for _, ov := range c.Overrides {
+		if len(ov.Files) == 0 {
+			return nil, errors.New("[[override]] without files")
+		}
+	}
+	return c, nil
+}
+
+// stripTOMLComment removes a comment from the end of line.
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseTOMLValue returns the value s as a string, or the elements of an
+// array of strings and true.
+func parseTOMLValue(s string) ([]string, bool, error) {
+	if strings.HasPrefix(s, "[") {
+		if !strings.HasSuffix(s, "]") {
+			return nil, false, errors.New("arrays must be on one line")
+		}
+		var values []string
+		rest := strings.TrimSpace(s[1 : len(s)-1])
+		for rest != "" {
+			v, n, err := parseTOMLString(rest)
+			if err != nil {
+				return nil, false, err
+			}
+			values = append(values, v)
+			rest = strings.TrimSpace(rest[n:])
+			if rest != "" {
+				if rest[0] != ',' {
+					return nil, false, fmt.Errorf("expected , in array: %s", rest)
+				}
+				rest = strings.TrimSpace(rest[1:])
+			}
+		}
+		return values, true, nil
+	}
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'") {
+		v, n, err := parseTOMLString(s)
+		if err != nil {
+			return nil, false, err
+		}
+		if n != len(s) {
+			return nil, false, fmt.Errorf("unexpected text after string: %s", s[n:])
+		}
+		return []string{v}, false, nil
+	}
+	switch s {
+	case "true", "false":
+		return []string{s}, false, nil
+	}
+	if _, err := strconv.ParseInt(strings.ReplaceAll(s, "_", ""), 10, 64); err != nil {
+		return nil, false, fmt.Errorf("expected a string, integer, boolean or array: %s", s)
+	}
+	return []string{strings.ReplaceAll(s, "_", "")}, false, nil
+}
+
+// parseTOMLString parses the string at the start of s and returns it and
+// its length in s.
+func parseTOMLString(s string) (string, int, error) {
+	if strings.HasPrefix(s, "'") {
+		// Literal strings have no escapes.
+		if i := strings.IndexByte(s[1:], '\''); i >= 0 {
+			return s[1 : i+1], i + 2, nil
+		}
+		return "", 0, errors.New("unterminated string")
+	}
+	if !strings.HasPrefix(s, `"`) {
+		return "", 0, fmt.Errorf("expected a string: %s", s)
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			v, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid string: %s", s[:i+1])
+			}
+			return v, i + 1, nil
+		}
+	}
+	return "", 0, errors.New("unterminated string")
+}
@@ -0,0 +1,117 @@
+package sqlfmt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name, data string
+		want       *Config
+		err        string
+	}{
+		{
+			name: "settings",
+			data: "# sqlfmt\nprint-width = 80\n\nuse-spaces = true # spaces\n",
+			want: &Config{Settings: []Setting{
+				{Name: "print-width", Value: "80", Line: 2},
+				{Name: "use-spaces", Value: "true", Line: 4},
+			}},
+		},
+		{
+			name: "quoting",
+			data: "\"casemode\" = 'lower'\nalign = \"p\\u0061rtial\"\nprint-width = 1_00\n",
+			want: &Config{Settings: []Setting{
+				{Name: "casemode", Value: "lower", Line: 1},
+				{Name: "align", Value: "partial", Line: 2},
+				{Name: "print-width", Value: "100", Line: 3},
+			}},
+		},
+		{
+			name: "hash in strings",
+			data: "[[override]]\nfiles = [\"a#b.sql\", 'c#d.sql'] # files\ncasemode = \"lower\" # \"x\"\n",
+			want: &Config{Overrides: []Override{{
+				Files:    []string{"a#b.sql", "c#d.sql"},
+				Settings: []Setting{{Name: "casemode", Value: "lower", Line: 3}},
+			}}},
+		},
+		{
+			name: "overrides",
+			data: "root = true\nprint-width = 80\n[[override]]\nfiles = \"migrations/**\"\nfiles = []\nprint-width = 100\n\n[[override]]\nfiles = ['*.psql', \"**/x/*.sql\",]\nuse-spaces = false\n",
+			want: &Config{
+				Root:     true,
+				Settings: []Setting{{Name: "print-width", Value: "80", Line: 2}},
+				Overrides: []Override{
+					{
+						Files:    []string{"migrations/**"},
+						Settings: []Setting{{Name: "print-width", Value: "100", Line: 6}},
+					},
+					{
+						Files:    []string{"*.psql", "**/x/*.sql"},
+						Settings: []Setting{{Name: "use-spaces", Value: "false", Line: 10}},
+					},
+				},
+			},
+		},
+		{name: "override without files", data: "[[override]]\nprint-width = 100\n", err: "[[override]] without files"},
+		{name: "root in override", data: "[[override]]\nfiles = '*'\nroot = true\n", err: "3: root: unknown option"},
+		{name: "root not bool", data: "root = 'yes'\n", err: "1: root must be a boolean"},
+		{name: "unknown table", data: "[options]\n", err: "1: unknown table"},
+		{name: "unknown option", data: "width = 80\nfoo = 1\n", err: "2: foo: unknown option"},
+		{name: "invalid value", data: "print-width = wide\n", err: "1: print-width: expected a string"},
+		{name: "array setting", data: "casemode = ['lower']\n", err: "1: casemode can't be an array"},
+		{name: "multi-line array", data: "[[override]]\nfiles = [\n'*'\n]\n", err: "2: files: arrays must be on one line"},
+		{name: "unterminated string", data: "casemode = \"lower\n", err: "1: casemode: unterminated string"},
+		{name: "text after string", data: "casemode = 'lower' x\n", err: "1: casemode: unexpected text after string"},
+		{name: "missing value", data: "casemode\n", err: "1: expected key = value"},
+		{name: "invalid pattern", data: "[[override]]\nfiles = '[a'\n", err: "2: files: syntax error in pattern"},
+	}
+	for _, tc := range tests {
+		c, err := parseConfig(tc.data)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: got error %v, want %q", tc.name, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(c, tc.want) {
+			t.Errorf("%s: got %+v, want %+v", tc.name, c, tc.want)
+		}
+	}
+}
+
+func TestOverrideMatches(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"*.sql", "/cfg/a.sql", true},
+		{"*.sql", "/cfg/x/y/a.sql", true},
+		{"*.sql", "/cfg/a.psql", false},
+		{"migrations/**", "/cfg/migrations/a.sql", true},
+		{"migrations/**", "/cfg/migrations/2024/a.sql", true},
+		{"migrations/**", "/cfg/x/migrations/a.sql", false},
+		{"migrations/*.sql", "/cfg/migrations/a.sql", true},
+		{"migrations/*.sql", "/cfg/migrations/2024/a.sql", false},
+		{"**/*.sql", "/cfg/a.sql", true},
+		{"**/*.sql", "/cfg/x/y/a.sql", true},
+		{"a/**/b.sql", "/cfg/a/b.sql", true},
+		{"a/**/b.sql", "/cfg/a/x/y/b.sql", true},
+		{"a/**/b.sql", "/cfg/a/x/c.sql", false},
+		{"x/[ab].sql", "/cfg/x/b.sql", true},
+		{"*.sql", "/other/a.sql", false},
+		{"**", "/cfgx/a.sql", false},
+	}
+	for _, tc := range tests {
+		ov := Override{Files: []string{tc.pattern}}
+		if got := ov.matches("/cfg", tc.path); got != tc.want {
+			t.Errorf("%q matching %s: got %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
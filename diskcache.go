@@ -0,0 +1,233 @@
+package sqlfmt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DiskCache stores formatted files in a directory, so files that were
+// already formatted with the same options and build of sqlfmt are only
+// read. Files are looked up by the hash of their content, so renamed and
+// copied files are found too. When the files in the directory take more
+// than the size limit, the least recently used are removed.
+type DiskCache struct {
+	dir     string
+	maxSize int64
+	mu      sync.Mutex
+	// size is the total size of the entries, or -1 if it hasn't been
+	// computed yet.
+	size int64
+}
+
+// NewDiskCache returns a DiskCache in dir, which is created if needed,
+// taking up to maxSize bytes, or no limit if maxSize is zero.
+func NewDiskCache(dir string, maxSize int64) *DiskCache {
+	return &DiskCache{dir: dir, maxSize: maxSize, size: -1}
+}
+
+// DefaultCacheDir returns the directory for a DiskCache shared by all users
+// of sqlfmt, in the user's cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sqlfmt"), nil
+}
+
+// diskEntry is a formatted file in a DiskCache.
+type diskEntry struct {
+	Output   string
+	Warnings []Warning `json:",omitempty"`
+}
+
+// Format is like FormatWithWarnings but returns the output from c if src was
+// formatted with the same options before. Errors aren't cached, and errors
+// reading or writing c are ignored. c isn't used if the build of sqlfmt
+// can't be identified.
+func (c *DiskCache) Format(ctx context.Context, src string, opts Options) (string, []Warning, error) {
+	if err := opts.Validate(); err != nil {
+		return "", nil, err
+	}
+	if buildVersion() == "" {
+		return FormatWithWarnings(ctx, src, opts)
+	}
+	path := c.path(src, opts)
+	if data, err := os.ReadFile(path); err == nil {
+		var e diskEntry
+		if err := json.Unmarshal(data, &e); err == nil {
+			// Mark it as recently used.
+			now := time.Now()
+			_ = os.Chtimes(path, now, now)
+			return e.Output, e.Warnings, nil
+		}
+	}
+	res, warns, err := FormatWithWarnings(ctx, src, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	if data, err := json.Marshal(diskEntry{Output: res, Warnings: warns}); err == nil {
+		c.write(path, data)
+	}
+	return res, warns, nil
+}
+
+// path returns the path of the entry for src formatted using opts.
+func (c *DiskCache) path(src string, opts Options) string {
+	// Options that don't change the output are left out.
+	opts.Concurrency, opts.MaxInputSize, opts.Timeout, opts.Cache = 0, 0, 0, nil
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%+v\x00", buildVersion(), opts)
+	h.Write([]byte(src))
+	sum := hex.EncodeToString(h.Sum(nil))
+	return filepath.Join(c.dir, sum[:2], sum)
+}
+
+// write atomically writes data to the entry at path, and removes old
+// entries if c is over its size limit.
+func (c *DiskCache) write(path string, data []byte) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if c.maxSize <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size < 0 {
+		// The new entry is counted by the walk.
+		c.trim(-1)
+	} else {
+		c.size += int64(len(data))
+	}
+	if c.size > c.maxSize {
+		// Trim to below the limit so the next writes don't trim again.
+		c.trim(c.maxSize * 9 / 10)
+	}
+}
+
+// trim computes the size of c and removes the least recently used entries
+// until it is at most target. A negative target only computes the size.
+func (c *DiskCache) trim(target int64) {
+	type entry struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var entries []entry
+	size := int64(0)
+	filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		entries = append(entries, entry{path, info.Size(), info.ModTime()})
+		size += info.Size()
+		return nil
+	})
+	if target >= 0 {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].used.Before(entries[j].used) })
+		for _, e := range entries {
+			if size <= target {
+				break
+			}
+			if os.Remove(e.path) == nil {
+				size -= e.size
+			}
+		}
+	}
+	c.size = size
+}
+
+var (
+	buildVersionOnce sync.Once
+	buildVersionText string
+)
+
+// buildVersion identifies the build of sqlfmt and its parser, so entries
+// made by other builds aren't used. It is "" if the build can't be told
+// apart from others.
+func buildVersion() string {
+	buildVersionOnce.Do(func() {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		v := fmt.Sprintf("options %d %s %s", OptionsVersion, info.Main.Path, info.Main.Version)
+		// Builds of a module version or of a commit are identified by
+		// them. Other builds, such as those with uncommitted changes or
+		// outside of version control, are only told apart by their
+		// executable.
+		known := info.Main.Version != "" && info.Main.Version != "(devel)"
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				v += " " + s.Value
+				known = true
+			case "vcs.modified":
+				if s.Value == "true" {
+					known = false
+				}
+			}
+		}
+		if !known {
+			sum, err := executableHash()
+			if err != nil {
+				return
+			}
+			v += " " + sum
+		}
+		for _, m := range info.Deps {
+			v += fmt.Sprintf(" %s %s %s", m.Path, m.Version, m.Sum)
+		}
+		buildVersionText = v
+	})
+	return buildVersionText
+}
+
+// executableHash returns the hash of the running executable.
+func executableHash() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,323 @@
+package sqlfmt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// EditorConfigFileName is the name of EditorConfig files, whose
+// indent_style, indent_size, tab_width and max_line_length properties set
+// UseTabs, TabWidth and LineWidth. See https://editorconfig.org.
+const EditorConfigFileName = ".editorconfig"
+
+// EditorConfig is an EditorConfig file.
+type EditorConfig struct {
+	// Path is the path of the file.
+	Path string
+	// Root is set if EditorConfig files above this one are ignored.
+	Root bool
+	// Sections are the sections of the file, in order.
+	Sections []EditorConfigSection
+}
+
+// EditorConfigSection is a section of an EditorConfig file.
+type EditorConfigSection struct {
+	// Pattern is the pattern of the files the section applies to.
+	Pattern string
+	// Properties are the properties set by the section, with lower case
+	// names and values.
+	Properties map[string]string
+}
+
+// ReadEditorConfig reads the EditorConfig file at path.
+func ReadEditorConfig(path string) (*EditorConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ec := &EditorConfig{Path: path}
+	var sec *EditorConfigSection
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			ec.Sections = append(ec.Sections, EditorConfigSection{
+				Pattern:    line[1 : len(line)-1],
+				Properties: map[string]string{},
+			})
+			sec = &ec.Sections[len(ec.Sections)-1]
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected key = value: %s", path, i+1, line)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.ToLower(strings.TrimSpace(value))
+		if sec != nil {
+			sec.Properties[key] = value
+		} else if key == "root" {
+			ec.Root = value == "true"
+		}
+	}
+	return ec, nil
+}
+
+// FindEditorConfigs returns the EditorConfig files that apply to the files
+// in dir, starting with the one furthest up.
+func FindEditorConfigs(dir string) ([]*EditorConfig, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var ecs []*EditorConfig
+	for {
+		ec, err := ReadEditorConfig(filepath.Join(dir, EditorConfigFileName))
+		if err == nil {
+			ecs = append([]*EditorConfig{ec}, ecs...)
+			if ec.Root {
+				break
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return ecs, nil
+}
+
+// ApplyEditorConfigs sets UseTabs, TabWidth and LineWidth from the
+// properties in ecs, which are ordered like the result of
+// FindEditorConfigs, that apply to the file at path. Properties with
+// invalid values are ignored, as EditorConfig requires.
+func (o *Options) ApplyEditorConfigs(ecs []*EditorConfig, path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	props := map[string]string{}
+	for _, ec := range ecs {
+		for _, sec := range ec.Sections {
+			if !sec.matches(filepath.Dir(ec.Path), abs) {
+				continue
+			}
+			for k, v := range sec.Properties {
+				if v == "unset" {
+					delete(props, k)
+				} else {
+					props[k] = v
+				}
+			}
+		}
+	}
+	switch props["indent_style"] {
+	case "tab":
+		o.UseTabs = true
+	case "space":
+		o.UseTabs = false
+	}
+	size := props["indent_size"]
+	if size == "" || size == "tab" {
+		size = props["tab_width"]
+	}
+	if n, err := strconv.Atoi(size); err == nil && n > 0 {
+		o.TabWidth = n
+	}
+	if n, err := strconv.Atoi(props["max_line_length"]); err == nil && n > 0 {
+		o.LineWidth = n
+	}
+	return nil
+}
+
+// matches reports whether sec applies to the file at path, which is
+// absolute, for an EditorConfig file in dir.
+func (sec EditorConfigSection) matches(dir, path string) bool {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return matchEditorConfig(sec.Pattern, filepath.ToSlash(rel))
+}
+
+// matchEditorConfig reports whether the slash-separated path rel matches the
+// EditorConfig glob pattern p. Patterns without a slash match file names in
+// any directory.
+func matchEditorConfig(p, rel string) bool {
+	expr, ranges := translateGlob(strings.TrimPrefix(p, "/"))
+	if strings.Contains(p, "/") {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return false
+	}
+	m := re.FindStringSubmatch(rel)
+	if m == nil {
+		return false
+	}
+	for i, r := range ranges {
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil || n < r[0] || n > r[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// numRange matches a numeric range in braces, like "{1..10}".
+var numRange = regexp.MustCompile(`^([+-]?\d+)\.\.([+-]?\d+)$`)
+
+// translateGlob returns a regular expression for the EditorConfig glob p,
+// in which each numeric range is a group matching an integer, and the
+// bounds of those ranges in order.
+func translateGlob(p string) (string, [][2]int) {
+	var sb strings.Builder
+	var ranges [][2]int
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '\\':
+			if i+1 < len(p) {
+				i++
+			}
+			sb.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		case '*':
+			if strings.HasPrefix(p[i:], "**") {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '/':
+			if strings.HasPrefix(p[i:], "/**/") {
+				// Also match no directories.
+				sb.WriteString("(?:/|/.*/)")
+				i += 3
+			} else {
+				sb.WriteString("/")
+			}
+		case '[':
+			end := closing(p, i, '[', ']')
+			if end < 0 || strings.Contains(p[i:end], "/") {
+				sb.WriteString(`\[`)
+				continue
+			}
+			sb.WriteString("[")
+			class := p[i+1 : end]
+			if strings.HasPrefix(class, "!") || strings.HasPrefix(class, "^") {
+				sb.WriteString("^")
+				class = class[1:]
+			}
+			for k := 0; k < len(class); k++ {
+				if class[k] == '\\' && k+1 < len(class) {
+					k++
+				}
+				if strings.IndexByte(`\[]^`, class[k]) >= 0 {
+					sb.WriteByte('\\')
+				}
+				sb.WriteByte(class[k])
+			}
+			sb.WriteString("]")
+			i = end
+		case '{':
+			end := closing(p, i, '{', '}')
+			if end < 0 {
+				sb.WriteString(`\{`)
+				continue
+			}
+			inner := p[i+1 : end]
+			i = end
+			if m := numRange.FindStringSubmatch(inner); m != nil {
+				lo, _ := strconv.Atoi(m[1])
+				hi, _ := strconv.Atoi(m[2])
+				if lo > hi {
+					lo, hi = hi, lo
+				}
+				sb.WriteString(`([+-]?\d+)`)
+				ranges = append(ranges, [2]int{lo, hi})
+				continue
+			}
+			alts := splitAlternatives(inner)
+			if len(alts) == 1 {
+				// Braces without alternatives match themselves.
+				expr, r := translateGlob(inner)
+				sb.WriteString(`\{` + expr + `\}`)
+				ranges = append(ranges, r...)
+				continue
+			}
+			sb.WriteString("(?:")
+			for k, alt := range alts {
+				if k > 0 {
+					sb.WriteString("|")
+				}
+				expr, r := translateGlob(alt)
+				sb.WriteString(expr)
+				ranges = append(ranges, r...)
+			}
+			sb.WriteString(")")
+		default:
+			sb.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	return sb.String(), ranges
+}
+
+// closing returns the position of the close bracket matching the open one
+// at p[start], or -1 if there is none. Escaped brackets are skipped.
+func closing(p string, start int, open, close byte) int {
+	depth := 0
+	for i := start; i < len(p); i++ {
+		switch p[i] {
+		case '\\':
+			i++
+		case open:
+			depth++
+		case close:
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitAlternatives splits the text inside braces at the commas that
+// aren't in nested braces or escaped.
+func splitAlternatives(s string) []string {
+	var alts []string
+	depth, prev := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				alts = append(alts, s[prev:i])
+				prev = i + 1
+			}
+		}
+	}
+	return append(alts, s[prev:])
+}
@@ -0,0 +1,73 @@
+package sqlfmt
+
+import "testing"
+
+func TestMatchEditorConfig(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"*", "a.sql", true},
+		{"*", "x/y/a.sql", true},
+		{"*.sql", "x/a.sql", true},
+		{"*.sql", "a.psql", false},
+		{"a.sql", "x/a.sql", true},
+		{"x/*.sql", "x/a.sql", true},
+		{"x/*.sql", "x/y/a.sql", false},
+		{"x/*.sql", "y/x/a.sql", false},
+		{"/x/*.sql", "x/a.sql", true},
+		{"migrations/**.sql", "migrations/b.sql", true},
+		{"migrations/**.sql", "migrations/2024/a.sql", true},
+		{"migrations/**", "migrations/2024/a.sql", true},
+		{"a/**/b.sql", "a/b.sql", true},
+		{"a/**/b.sql", "a/x/y/b.sql", true},
+		{"a/**/b.sql", "a/x/c.sql", false},
+		{"**/x/*.sql", "x/a.sql", false},
+		{"**/x/*.sql", "y/x/a.sql", true},
+		{"?.sql", "a.sql", true},
+		{"?.sql", "ab.sql", false},
+		{"x?a.sql", "x/a.sql", false},
+		{"[ab].sql", "b.sql", true},
+		{"[ab].sql", "c.sql", false},
+		{"[!ab].sql", "c.sql", true},
+		{"[!ab].sql", "a.sql", false},
+		{"[a-c].sql", "b.sql", true},
+		{"*.{sql,psql}", "a.psql", true},
+		{"*.{sql,psql}", "a.txt", false},
+		{"{a,{b,c}d}.sql", "cd.sql", true},
+		{"{a,{b,c}d}.sql", "c.sql", false},
+		{"{,x}a.sql", "a.sql", true},
+		{"{single}.sql", "{single}.sql", true},
+		{"{single}.sql", "single.sql", false},
+		{"v{1..10}.sql", "v3.sql", true},
+		{"v{1..10}.sql", "v10.sql", true},
+		{"v{1..10}.sql", "v11.sql", false},
+		{"v{-5..5}.sql", "v-2.sql", true},
+		{"v{1..3}_{1..3}.sql", "v2_3.sql", true},
+		{"v{1..3}_{1..3}.sql", "v2_4.sql", false},
+		{`\*.sql`, "*.sql", true},
+		{`\*.sql`, "a.sql", false},
+		{"a[.sql", "a[.sql", true},
+		{"a{.sql", "a{.sql", true},
+		{"a+(b).sql", "a+(b).sql", true},
+	}
+	for _, tc := range tests {
+		if got := matchEditorConfig(tc.pattern, tc.path); got != tc.want {
+			t.Errorf("%q matching %s: got %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestEditorConfigSectionMatches(t *testing.T) {
+	sec := EditorConfigSection{Pattern: "migrations/**.sql"}
+	for path, want := range map[string]bool{
+		"/ec/migrations/2024/a.sql": true,
+		"/ec/migrations/a.sql":      true,
+		"/ec/a.sql":                 false,
+		"/other/migrations/a.sql":   false,
+	} {
+		if got := sec.matches("/ec", path); got != want {
+			t.Errorf("%s: got %v, want %v", path, got, want)
+		}
+	}
+}
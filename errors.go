@@ -0,0 +1,252 @@
+package sqlfmt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/pgwire/pgerror"
+)
+
+// ParseError is returned when a statement can't be parsed.
+type ParseError struct {
+	// Statement is the index of the statement in the input, starting at 0.
+	Statement int
+	// Offset is the byte offset of the error in the input.
+	Offset int
+	// Line and Column are the 1-based position of Offset. Column counts
+	// characters, not bytes.
+	Line, Column int
+	// Message is the parser's description of the error.
+	Message string
+	// Hint and Detail are additional information from the parser, if any.
+	Hint, Detail string
+
+	// srcLine is the line of input containing Offset. lineCol is the
+	// column where srcLine starts if it isn't the whole line.
+	srcLine string
+	lineCol int
+	err     error
+}
+
+// sourceSQLDetail is how the parser introduces the statement excerpt in
+// its error details.
+const sourceSQLDetail = "source SQL:\n"
+
+// newParseError converts err, returned by the parser for stmt, into a
+// ParseError. stmt starts at byte off of src.
+func newParseError(err error, src string, off int, stmt string, idx int) *ParseError {
+	flat := pgerror.Flatten(err)
+	e := &ParseError{
+		Statement: idx,
+		Offset:    off,
+		Message:   flat.Message,
+		Hint:      flat.Hint,
+		Detail:    flat.Detail,
+		err:       err,
+	}
+	// The parser reports the position as an excerpt of the statement,
+	// starting at its first token, up to the end of the erroneous line
+	// followed by a line with a caret. Use that to find the position and
+	// remove it from the detail, since Snippet does the same for the whole
+	// input.
+	if i := strings.Index(e.Detail, sourceSQLDetail); i >= 0 {
+		rest := e.Detail[i+len(sourceSQLDetail):]
+		for k := 0; k < len(rest); k++ {
+			if rest[k] != '\n' {
+				continue
+			}
+			caret := rest[k+1:]
+			spaces := len(caret) - len(strings.TrimLeft(caret, " "))
+			if !strings.HasPrefix(caret[spaces:], "^") {
+				continue
+			}
+			if end := k + 1 + spaces + 1; end == len(rest) || rest[end] == '\n' {
+				if toks := tokenize(stmt); len(toks) > 0 {
+					e.Offset += toks[0].start
+				}
+				e.Offset += lineStart(rest, k) + spaces
+				e.Detail = strings.Trim(e.Detail[:i]+rest[end:], "\n-")
+				break
+			}
+		}
+	}
+	if e.Offset > len(src) {
+		e.Offset = len(src)
+	}
+	e.setPosition(src)
+	return e
+}
+
+// setPosition sets the line and column of e from its offset into src.
+func (e *ParseError) setPosition(src string) {
+	start := lineStart(src, e.Offset)
+	e.srcLine = src[start:lineEnd(src, e.Offset)]
+	e.Line = strings.Count(src[:start], "\n") + 1
+	e.Column = utf8.RuneCountInString(src[start:e.Offset]) + 1
+}
+
+// rebaseError adjusts the positions of the errors in err, which were found
+// in the part of src starting at off.
+func rebaseError(err error, src string, off int) error {
+	lines := strings.Count(src[:off], "\n")
+	var ve *VerifyError
+	if errors.As(err, &ve) {
+		ve.Line += lines
+	}
+	var de *DirectiveError
+	if errors.As(err, &de) {
+		de.Line += lines
+	}
+	var pes ParseErrors
+	if errors.As(err, &pes) {
+		for _, pe := range pes {
+			pe.rebase(src, off)
+		}
+		return err
+	}
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		pe.rebase(src, off)
+	}
+	return err
+}
+
+// rebase adjusts e, which was found in the part of src starting at off.
+func (e *ParseError) rebase(src string, off int) {
+	e.Offset += off
+	e.setPosition(src)
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.err
+}
+
+// Snippet returns the line of input containing the error followed by a
+// line with a caret under the error position.
+func (e *ParseError) Snippet() string {
+	var sb strings.Builder
+	sb.WriteString(strings.TrimRight(e.srcLine, "\r"))
+	sb.WriteString("\n")
+	// Keep tabs so the caret lines up regardless of tab width, and pad
+	// other characters by their display width.
+	n, w := 0, 0
+	prev := rune(0)
+	for _, r := range e.srcLine {
+		if n == e.Column-1-e.lineCol {
+			break
+		}
+		n++
+		if r == '\t' {
+			sb.WriteByte('\t')
+		} else {
+			next := advance(w, prev, r, 0)
+			sb.WriteString(strings.Repeat(" ", next-w))
+			w = next
+		}
+		prev = r
+	}
+	sb.WriteString("^")
+	return sb.String()
+}
+
+// ParseErrors is a list of parse errors, returned when
+// Options.AllErrors is set.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	switch len(e) {
+	case 0:
+		return "no errors"
+	case 1:
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", e[0], len(e)-1)
+}
+
+// VerifyError is returned when Options.Verify is set and formatting
+// changed the meaning of a statement or isn't idempotent.
+type VerifyError struct {
+	// Statement is the index of the statement in the input, starting at 0.
+	Statement int
+	// Line is the 1-based line of the input where the statement starts.
+	Line int
+	// Message describes the mismatch.
+	Message string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Line, e.Message)
+}
+
+// DirectiveError is returned when a directive comment can't be applied.
+type DirectiveError struct {
+	// Line is the 1-based line of the input where the directive starts.
+	Line int
+	// Directive is the comment.
+	Directive string
+	// Message describes the problem.
+	Message string
+}
+
+func (e *DirectiveError) Error() string {
+	return fmt.Sprintf("%d: invalid directive %q: %s", e.Line, e.Directive, e.Message)
+}
+
+// LimitError is returned when formatting is stopped because it exceeded
+// a limit in Options or its context was done.
+type LimitError struct {
+	// Message describes the limit that was exceeded.
+	Message string
+	err     error
+}
+
+func (e *LimitError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the context's error if formatting was stopped by its
+// context.
+func (e *LimitError) Unwrap() error {
+	return e.err
+}
+
+// contextError returns a LimitError for ctx, which is done.
+func contextError(ctx context.Context) error {
+	err := ctx.Err()
+	return &LimitError{Message: "formatting stopped: " + err.Error(), err: err}
+}
+
+// Warning describes a problem that didn't prevent formatting.
+type Warning struct {
+	// Statement is the index of the statement in the input, starting at 0.
+	Statement int
+	// Line is the 1-based line of the input where the statement starts.
+	Line int
+	// Message describes the problem.
+	Message string
+	// Overflow is set if the problem is a line of output that is wider
+	// than the line width.
+	Overflow *Overflow
+}
+
+// Overflow describes a line of output that is wider than the line width,
+// usually because it has a long string or identifier that can't be broken.
+type Overflow struct {
+	// Line is the 1-based line of the output.
+	Line int
+	// Width is the display width of the line.
+	Width int
+	// Token is the text at the line width.
+	Token string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%d: %s", w.Line, w.Message)
+}
@@ -0,0 +1,29 @@
+package sqlfmt
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseErrorSnippet(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"select 1 frm t;", "select 1 frm t;\n             ^"},
+		{"select\t1 frm t;", "select\t1 frm t;\n      \t      ^"},
+		// Wide characters take two columns.
+		{"select 日本, 1 frm t;", "select 日本, 1 frm t;\n                   ^"},
+		{"select é, 1 frm t;", "select é, 1 frm t;\n                ^"},
+	}
+	for _, tc := range tests {
+		_, err := Format(context.Background(), tc.src, DefaultOptions())
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Fatalf("%s: got %v, want a ParseError", tc.src, err)
+		}
+		if got := pe.Snippet(); got != tc.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tc.src, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package sqlfmt
+
+import (
+	"sort"
+	"strings"
+)
+
+// LineEnding is the line ending used in the output.
+type LineEnding string
+
+const (
+	// LineEndingAuto uses the line ending of most lines of the input, or
+	// LF if it has none. The empty LineEnding is LineEndingAuto.
+	LineEndingAuto LineEnding = "auto"
+	LineEndingLF   LineEnding = "lf"
+	LineEndingCRLF LineEnding = "crlf"
+)
+
+// BOMMode controls whether the output starts with a UTF-8 byte order mark.
+// The empty BOMMode is BOMKeep.
+type BOMMode string
+
+const (
+	// BOMKeep starts the output with a byte order mark if the input
+	// starts with one.
+	BOMKeep   BOMMode = "keep"
+	BOMAlways BOMMode = "always"
+	BOMNever  BOMMode = "never"
+)
+
+// FinalNewline controls whether the output ends with a line ending. The
+// empty FinalNewline is FinalNewlineNever.
+type FinalNewline string
+
+const (
+	FinalNewlineNever  FinalNewline = "never"
+	FinalNewlineAlways FinalNewline = "always"
+	// FinalNewlineKeep ends the output with a line ending if the input
+	// ends with one.
+	FinalNewlineKeep FinalNewline = "keep"
+)
+
+// bom is the UTF-8 byte order mark.
+const bom = "\uFEFF"
+
+// verbatimRange is a range of output that was copied unchanged from the
+// input, so its line endings are kept.
+type verbatimRange struct {
+	start, end int
+}
+
+// layout is how formatted output is encoded.
+type layout struct {
+	bom, crlf, finalNewline bool
+}
+
+// layout returns the layout of the output for src.
+func (o Options) layout(src string) layout {
+	var l layout
+	switch o.BOM {
+	case BOMAlways:
+		l.bom = true
+	case BOMNever:
+	default:
+		l.bom = strings.HasPrefix(src, bom)
+	}
+	switch o.LineEnding {
+	case LineEndingCRLF:
+		l.crlf = true
+	case LineEndingLF:
+	default:
+		crlf := strings.Count(src, "\r\n")
+		l.crlf = crlf > strings.Count(src, "\n")-crlf
+	}
+	switch o.FinalNewline {
+	case FinalNewlineAlways:
+		l.finalNewline = true
+	case FinalNewlineKeep:
+		l.finalNewline = strings.HasSuffix(src, "\n")
+	}
+	return l
+}
+
+// eol returns the line ending of l.
+func (l layout) eol() string {
+	if l.crlf {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// encode returns res, which uses LF line endings except in text copied from
+// the input, encoded using l. The line endings in the verbatim ranges of res
+// are kept. The output offsets of spans are updated to match.
+func (l layout) encode(res string, verbatim []verbatimRange, spans []span) string {
+	if res == "" {
+		return ""
+	}
+	res, move := l.convert(res, verbatim)
+	if l.bom {
+		res = bom + res
+	}
+	if l.finalNewline {
+		res += l.eol()
+	}
+	for i := range spans {
+		spans[i].outStart = move(spans[i].outStart)
+		spans[i].outEnd = move(spans[i].outEnd)
+	}
+	return res
+}
+
+// convert returns s with its line endings outside the verbatim ranges, which
+// are ordered, converted to those of l, and a function that moves offsets
+// in s to the corresponding ones in the result, including a byte order mark
+// before it if l has one.
+func (l layout) convert(s string, verbatim []verbatimRange) (string, func(int) int) {
+	var sb strings.Builder
+	// changes are the offsets in s where a CR was added or removed.
+	var changes []int
+	for i := 0; i < len(s); i++ {
+		for len(verbatim) > 0 && verbatim[0].end <= i {
+			verbatim = verbatim[1:]
+		}
+		switch c := s[i]; {
+		case len(verbatim) > 0 && verbatim[0].start <= i:
+			sb.WriteByte(c)
+		case c == '\n' && l.crlf && (i == 0 || s[i-1] != '\r'):
+			changes = append(changes, i)
+			sb.WriteString("\r\n")
+		case c == '\r' && !l.crlf && i+1 < len(s) && s[i+1] == '\n':
+			changes = append(changes, i)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	delta := -1
+	if l.crlf {
+		delta = 1
+	}
+	return sb.String(), func(off int) int {
+		off += delta * sort.SearchInts(changes, off)
+		if l.bom {
+			off += len(bom)
+		}
+		return off
+	}
+}
@@ -0,0 +1,112 @@
+package sqlfmt
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLayoutConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		l        layout
+		s        string
+		verbatim []verbatimRange
+		want     string
+		// moves are pairs of offsets in s and in the result.
+		moves [][2]int
+	}{
+		{
+			name:  "lf to crlf",
+			l:     layout{crlf: true},
+			s:     "a\nb\r\nc\n",
+			want:  "a\r\nb\r\nc\r\n",
+			moves: [][2]int{{0, 0}, {1, 1}, {2, 3}, {5, 6}, {7, 9}},
+		},
+		{
+			name:  "crlf to lf",
+			s:     "a\r\nb\nc\r\n",
+			want:  "a\nb\nc\n",
+			moves: [][2]int{{0, 0}, {1, 1}, {3, 2}, {5, 4}, {8, 6}},
+		},
+		{
+			name:  "bom",
+			l:     layout{bom: true},
+			s:     "a\nb",
+			want:  "a\nb",
+			moves: [][2]int{{0, 3}, {2, 5}, {3, 6}},
+		},
+		{
+			name:     "verbatim crlf",
+			s:        "a\r\nb\r\nc\r\n",
+			verbatim: []verbatimRange{{3, 6}},
+			want:     "a\nb\r\nc\n",
+			moves:    [][2]int{{2, 1}, {3, 2}, {6, 5}, {9, 7}},
+		},
+		{
+			name:     "verbatim lf",
+			l:        layout{crlf: true},
+			s:        "a\nb\nc\nd\n",
+			verbatim: []verbatimRange{{0, 2}, {4, 6}},
+			want:     "a\nb\r\nc\nd\r\n",
+			moves:    [][2]int{{2, 2}, {4, 5}, {6, 7}, {8, 10}},
+		},
+	}
+	for _, tc := range tests {
+		got, move := tc.l.convert(tc.s, tc.verbatim)
+		if got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+		for _, m := range tc.moves {
+			if off := move(m[0]); off != m[1] {
+				t.Errorf("%s: moved %d to %d, want %d", tc.name, m[0], off, m[1])
+			}
+		}
+	}
+}
+
+func TestLayoutOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		opts func(*Options)
+		want string
+	}{
+		{"defaults", "select 1", func(*Options) {}, "SELECT 1;\n"},
+		{"auto crlf", "select 1;\r\nselect 2;\r\n", func(*Options) {}, "SELECT 1;\r\n\r\nSELECT 2;\r\n"},
+		{"auto mostly lf", "select 1;\r\nselect 2;\nselect 3;\n", func(*Options) {}, "SELECT 1;\n\nSELECT 2;\n\nSELECT 3;\n"},
+		{"crlf", "select 1;\nselect 2;\n", func(o *Options) { o.LineEnding = LineEndingCRLF }, "SELECT 1;\r\n\r\nSELECT 2;\r\n"},
+		{"lf", "select 1;\r\nselect 2;\r\n", func(o *Options) { o.LineEnding = LineEndingLF }, "SELECT 1;\n\nSELECT 2;\n"},
+		{"keep bom", bom + "select 1;\n", func(*Options) {}, bom + "SELECT 1;\n"},
+		{"keep no bom", "select 1;\n", func(*Options) {}, "SELECT 1;\n"},
+		{"always bom", "select 1;\n", func(o *Options) { o.BOM = BOMAlways }, bom + "SELECT 1;\n"},
+		{"never bom", bom + "select 1;\n", func(o *Options) { o.BOM = BOMNever }, "SELECT 1;\n"},
+		{"final newline never", "select 1;\n", func(o *Options) { o.FinalNewline = FinalNewlineNever }, "SELECT 1;"},
+		{"final newline keep", "select 1;\n", func(o *Options) { o.FinalNewline = FinalNewlineKeep }, "SELECT 1;\n"},
+		{"final newline keep none", "select 1;", func(o *Options) { o.FinalNewline = FinalNewlineKeep }, "SELECT 1;"},
+		{"final newline crlf", "select 1;\r\n", func(*Options) {}, "SELECT 1;\r\n"},
+		{"empty", "", func(o *Options) { o.BOM = BOMAlways }, ""},
+		{
+			"verbatim",
+			"select 1;\r\n-- sqlfmt: off\nselect   2,\r\n  3;\n-- sqlfmt: on\r\nselect 4;\r\n",
+			func(*Options) {},
+			"SELECT 1;\r\n\r\n-- sqlfmt: off\nselect   2,\r\n  3;\n-- sqlfmt: on\r\nSELECT 4;\r\n",
+		},
+		{
+			"tolerant",
+			"select 1;\r\nselec 2\n  ;\r\nselect 3;\r\n",
+			func(o *Options) { o.Tolerant = true },
+			"SELECT 1;\r\n\r\nselec 2\n  ;\r\nSELECT 3;\r\n",
+		},
+	}
+	for _, tc := range tests {
+		opts := DefaultOptions()
+		tc.opts(&opts)
+		got, err := Format(context.Background(), tc.src, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
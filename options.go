@@ -0,0 +1,384 @@
+package sqlfmt
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+)
+
+// OptionsVersion is the current version of Options. It is incremented
+// whenever the meaning of an existing field changes.
+const OptionsVersion = 1
+
+// CaseMode is the casing applied to keywords. The empty CaseMode leaves
+// keywords as the formatter produces them.
+type CaseMode string
+
+const (
+	CaseUpper     CaseMode = "upper"
+	CaseLower     CaseMode = "lower"
+	CaseTitle     CaseMode = "title"
+	CaseSpongeBob CaseMode = "spongebob"
+)
+
+// AlignMode is the keyword alignment style. See the about page of the web
+// server for examples of each. The empty AlignMode is AlignNone.
+type AlignMode string
+
+const (
+	// AlignNone uses left alignment.
+	AlignNone AlignMode = "no"
+	// AlignPartial right aligns keywords at the width of the longest
+	// keyword at the beginning of all lines immediately below.
+	AlignPartial AlignMode = "partial"
+	// AlignFull is AlignPartial but also deindents AND and OR.
+	AlignFull AlignMode = "full"
+	// AlignOther is AlignPartial but also indents the arguments of AND
+	// and OR.
+	AlignOther AlignMode = "other"
+)
+
+var alignModes = map[AlignMode]tree.PrettyAlignMode{
+	"":           tree.PrettyNoAlign,
+	AlignNone:    tree.PrettyNoAlign,
+	AlignPartial: tree.PrettyAlignOnly,
+	AlignFull:    tree.PrettyAlignAndDeindent,
+	AlignOther:   tree.PrettyAlignAndExtraIndent,
+}
+
+// Options control how SQL is formatted.
+type Options struct {
+	// Version is the version of these options. Zero means OptionsVersion.
+	Version int
+	// LineWidth is the line length where sqlfmt will try to wrap.
+	LineWidth int
+	// TabWidth is the number of spaces per indentation level.
+	TabWidth int
+	// UseTabs indents with tabs instead of spaces.
+	UseTabs bool
+	// Simplify removes unneeded parentheses.
+	Simplify bool
+	// Align is the keyword alignment style.
+	Align AlignMode
+	// Case is the keyword casing.
+	Case CaseMode
+	// JSONFmt pretty prints strings that are cast to JSON.
+	JSONFmt bool
+	// AllErrors continues past statements that can't be parsed and
+	// reports all of them in a ParseErrors instead of stopping at the
+	// first.
+	AllErrors bool
+	// Tolerant copies statements that can't be parsed to the output
+	// unchanged, along with the whitespace after them, and reports them as
+	// warnings instead of failing.
+	Tolerant bool
+	// MaxBlankLines is the maximum number of consecutive blank lines in the
+	// output, outside of text copied unchanged from the input.
+	MaxBlankLines int
+	// KeepBlankLines keeps the blank lines between statements, up to
+	// MaxBlankLines, instead of separating statements by one blank line,
+	// or none if MaxBlankLines is zero.
+	KeepBlankLines bool
+	// CollapseCommentGap removes the blank lines between the comments
+	// before a statement and the statement.
+	CollapseCommentGap bool
+	// LineEnding is the line ending of the output. Statements and comments
+	// copied unchanged from the input keep their line endings.
+	LineEnding LineEnding
+	// BOM controls whether the output starts with a byte order mark.
+	BOM BOMMode
+	// FinalNewline controls whether the output ends with a line ending.
+	FinalNewline FinalNewline
+	// Verify checks that each formatted statement parses to the same
+	// statement as its input and is unchanged when formatted again, and
+	// returns a *VerifyError if not.
+	Verify bool
+	// Concurrency is the maximum number of statements formatted at once.
+	// Zero means runtime.GOMAXPROCS(0). The output doesn't depend on it.
+	// FormatStream always formats one statement at a time.
+	Concurrency int
+	// MaxInputSize is the maximum number of bytes of input, or zero for no
+	// limit.
+	MaxInputSize int
+	// Timeout is the maximum time formatting can take, or zero for no
+	// limit. Formatting is also stopped when the context passed to Format
+	// is done. A statement can't be stopped while it is being pretty
+	// printed, so although Format returns when the time is up, a goroutine
+	// keeps formatting that statement in the background until it is done.
+	// Callers that format untrusted input should limit MaxInputSize and the
+	// number of calls that run at once.
+	Timeout time.Duration
+	// Cache, if set, holds formatted statements that are reused when the
+	// same statement is formatted again with the same options.
+	Cache *Cache
+}
+
+// DefaultOptions returns the options used by the sqlfmt command when no
+// flags are given.
+func DefaultOptions() Options {
+	return Options{
+		Version:       OptionsVersion,
+		LineWidth:     tree.DefaultLineWidth,
+		TabWidth:      4,
+		UseTabs:       true,
+		Simplify:      true,
+		Align:         AlignNone,
+		Case:          CaseUpper,
+		JSONFmt:       true,
+		MaxBlankLines: 1,
+		LineEnding:    LineEndingAuto,
+		BOM:           BOMKeep,
+		FinalNewline:  FinalNewlineAlways,
+	}
+}
+
+// Validate returns an error if o can't be used for formatting.
+func (o Options) Validate() error {
+	if o.Version < 0 || o.Version > OptionsVersion {
+		return fmt.Errorf("unsupported options version: %d", o.Version)
+	}
+	if o.LineWidth < 1 {
+		return fmt.Errorf("line length must be > 0: %d", o.LineWidth)
+	}
+	if o.TabWidth < 1 {
+		return fmt.Errorf("tab width must be > 0: %d", o.TabWidth)
+	}
+	if o.MaxBlankLines < 0 {
+		return fmt.Errorf("max blank lines must be >= 0: %d", o.MaxBlankLines)
+	}
+	if o.Concurrency < 0 {
+		return fmt.Errorf("concurrency must be >= 0: %d", o.Concurrency)
+	}
+	if o.MaxInputSize < 0 {
+		return fmt.Errorf("max input size must be >= 0: %d", o.MaxInputSize)
+	}
+	if o.Timeout < 0 {
+		return fmt.Errorf("timeout must be >= 0: %s", o.Timeout)
+	}
+	if _, ok := alignModes[o.Align]; !ok {
+		return fmt.Errorf("unknown align mode: %s", o.Align)
+	}
+	if _, ok := caseModes[string(o.Case)]; !ok && o.Case != "" {
+		return fmt.Errorf("unknown casemode: %s", o.Case)
+	}
+	switch o.LineEnding {
+	case "", LineEndingAuto, LineEndingLF, LineEndingCRLF:
+	default:
+		return fmt.Errorf("unknown line ending: %s", o.LineEnding)
+	}
+	switch o.BOM {
+	case "", BOMKeep, BOMAlways, BOMNever:
+	default:
+		return fmt.Errorf("unknown BOM mode: %s", o.BOM)
+	}
+	switch o.FinalNewline {
+	case "", FinalNewlineNever, FinalNewlineAlways, FinalNewlineKeep:
+	default:
+		return fmt.Errorf("unknown final newline mode: %s", o.FinalNewline)
+	}
+	return nil
+}
+
+// Set sets the option called name, which is the name of the sqlfmt
+// command's flag for the option or one of the shorter aliases: width,
+// indent, spaces, simplify, case, eol.
+func (o *Options) Set(name, value string) error {
+	switch name {
+	case "print-width", "width":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		o.LineWidth = n
+	case "tab-width", "indent":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		o.TabWidth = n
+	case "use-spaces", "spaces":
+		b, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		o.UseTabs = !b
+	case "no-simplify", "simplify":
+		b, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		o.Simplify = b != (name == "no-simplify")
+	case "casemode", "case":
+		o.Case = CaseMode(value)
+	case "align":
+		// The sqlfmt command's flag is a boolean that enables full
+		// alignment.
+		if b, err := parseBool(value); err == nil {
+			o.Align = AlignNone
+			if b {
+				o.Align = AlignFull
+			}
+		} else {
+			o.Align = AlignMode(value)
+		}
+	case "max-blank-lines":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		o.MaxBlankLines = n
+	case "keep-blank-lines":
+		b, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		o.KeepBlankLines = b
+	case "collapse-comment-gap":
+		b, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		o.CollapseCommentGap = b
+	case "line-ending", "eol":
+		o.LineEnding = LineEnding(value)
+	case "bom":
+		o.BOM = BOMMode(value)
+	case "final-newline":
+		o.FinalNewline = FinalNewline(value)
+	default:
+		return fmt.Errorf("unknown option: %s", name)
+	}
+	return nil
+}
+
+// Settings returns the options that can be set by Set, by the names of the
+// sqlfmt command's flags, with values that Set accepts.
+func (o Options) Settings() []Setting {
+	return []Setting{
+		{Name: "print-width", Value: strconv.Itoa(o.LineWidth)},
+		{Name: "tab-width", Value: strconv.Itoa(o.TabWidth)},
+		{Name: "use-spaces", Value: strconv.FormatBool(!o.UseTabs)},
+		{Name: "simplify", Value: strconv.FormatBool(o.Simplify)},
+		{Name: "casemode", Value: string(o.Case)},
+		{Name: "align", Value: string(o.Align)},
+		{Name: "max-blank-lines", Value: strconv.Itoa(o.MaxBlankLines)},
+		{Name: "keep-blank-lines", Value: strconv.FormatBool(o.KeepBlankLines)},
+		{Name: "collapse-comment-gap", Value: strconv.FormatBool(o.CollapseCommentGap)},
+		{Name: "line-ending", Value: string(o.LineEnding)},
+		{Name: "bom", Value: string(o.BOM)},
+		{Name: "final-newline", Value: string(o.FinalNewline)},
+	}
+}
+
+// directiveOptions are the names of the options that can be set by
+// directive comments. The others apply to the whole input.
+var directiveOptions = map[string]bool{
+	"print-width": true, "width": true,
+	"tab-width": true, "indent": true,
+	"use-spaces": true, "spaces": true,
+	"no-simplify": true, "simplify": true,
+	"casemode": true, "case": true,
+	"align": true,
+}
+
+// setDirective sets the options in a directive comment of the form
+// "name=value name=value...".
+func (o *Options) setDirective(d string) error {
+	for _, f := range strings.Fields(d) {
+		name, value, ok := strings.Cut(f, "=")
+		if !ok {
+			return fmt.Errorf("expected name=value: %s", f)
+		}
+		if err := o.Set(name, value); err != nil {
+			return err
+		}
+		if !directiveOptions[name] {
+			return fmt.Errorf("%s can't be set for a single statement", name)
+		}
+	}
+	return o.Validate()
+}
+
+// cfgOptions returns the Options equivalent to cfg. Keyword casing can't
+// be converted, so it is left empty.
+func cfgOptions(cfg tree.PrettyCfg) Options {
+	o := Options{
+		LineWidth:     cfg.LineWidth,
+		TabWidth:      cfg.TabWidth,
+		UseTabs:       cfg.UseTabs,
+		Simplify:      cfg.Simplify,
+		JSONFmt:       cfg.JSONFmt,
+		MaxBlankLines: 1,
+	}
+	for m, a := range alignModes {
+		if a == cfg.Align && m != "" {
+			o.Align = m
+		}
+	}
+	return o
+}
+
+// override returns cfg with the options that differ between from and to
+// set to their values in to.
+func override(cfg tree.PrettyCfg, from, to Options) tree.PrettyCfg {
+	if from.LineWidth != to.LineWidth {
+		cfg.LineWidth = to.LineWidth
+	}
+	if from.TabWidth != to.TabWidth {
+		cfg.TabWidth = to.TabWidth
+	}
+	if from.UseTabs != to.UseTabs {
+		cfg.UseTabs = to.UseTabs
+	}
+	if from.Simplify != to.Simplify {
+		cfg.Simplify = to.Simplify
+	}
+	if from.Align != to.Align {
+		cfg.Align = alignModes[to.Align]
+	}
+	if from.Case != to.Case {
+		cfg.Case = caseModes[string(to.Case)]
+	}
+	if from.JSONFmt != to.JSONFmt {
+		cfg.JSONFmt = to.JSONFmt
+	}
+	return cfg
+}
+
+// prettyCfg returns the tree.PrettyCfg for o, which must be valid.
+func (o Options) prettyCfg() tree.PrettyCfg {
+	cfg := tree.DefaultPrettyCfg()
+	cfg.LineWidth = o.LineWidth
+	cfg.TabWidth = o.TabWidth
+	cfg.UseTabs = o.UseTabs
+	cfg.Simplify = o.Simplify
+	cfg.Align = alignModes[o.Align]
+	cfg.Case = caseModes[string(o.Case)]
+	cfg.JSONFmt = o.JSONFmt
+	return cfg
+}
+
+// Format formats the SQL statements in src. If a limit in opts is exceeded
+// or ctx is done, it returns a *LimitError.
+func Format(ctx context.Context, src string, opts Options) (string, error) {
+	res, _, err := FormatWithWarnings(ctx, src, opts)
+	return res, err
+}
+
+// FormatWithWarnings is like Format but also returns warnings about
+// statements that were formatted with problems.
+func FormatWithWarnings(ctx context.Context, src string, opts Options) (string, []Warning, error) {
+	if err := opts.Validate(); err != nil {
+		return "", nil, err
+	}
+	f := &formatter{cfg: opts.prettyCfg(), opts: opts}
+	res, err := f.formatSource(ctx, src)
+	if err != nil {
+		return "", nil, err
+	}
+	return res, f.warns, nil
+}
@@ -0,0 +1,86 @@
+package sqlfmt
+
+import (
+	"context"
+	"sort"
+)
+
+// span is a range of input that was copied or formatted to a range of
+// output.
+type span struct {
+	inStart, inEnd   int
+	outStart, outEnd int
+}
+
+// tokenSpans returns the spans of the tokens of in that are also in out,
+// the formatted version of in.
+func tokenSpans(in, out string) []span {
+	inToks, outToks := tokenize(in), tokenize(out)
+	var spans []span
+	for i, k := range alignTokens(inToks, outToks) {
+		if k >= 0 {
+			spans = append(spans, span{inToks[i].start, inToks[i].end, outToks[k].start, outToks[k].end})
+		}
+	}
+	return spans
+}
+
+// PositionMap maps offsets in the input of FormatWithPositions to offsets
+// in its output and back. It only knows where tokens and comments before
+// statements were moved, so offsets between them map to the end of the
+// one before, and comments inside statements map to the token before them.
+type PositionMap struct {
+	// spans are ordered by both input and output offset.
+	spans         []span
+	inLen, outLen int
+}
+
+// Output returns the offset in the output corresponding to the input
+// offset off.
+func (m PositionMap) Output(off int) int {
+	i := sort.Search(len(m.spans), func(i int) bool { return m.spans[i].inStart > off }) - 1
+	if i < 0 {
+		return 0
+	}
+	s := m.spans[i]
+	return clamp(s.outStart+off-s.inStart, s.outEnd, m.outLen)
+}
+
+// Input returns the offset in the input corresponding to the output
+// offset off.
+func (m PositionMap) Input(off int) int {
+	i := sort.Search(len(m.spans), func(i int) bool { return m.spans[i].outStart > off }) - 1
+	if i < 0 {
+		return 0
+	}
+	s := m.spans[i]
+	return clamp(s.inStart+off-s.outStart, s.inEnd, m.inLen)
+}
+
+// clamp returns the smallest of off, end and max.
+func clamp(off, end, max int) int {
+	if off > end {
+		off = end
+	}
+	if off > max {
+		off = max
+	}
+	return off
+}
+
+// FormatWithPositions is like Format but also returns a map between offsets
+// in src and in the result, so editors can keep the cursor at the same
+// place.
+func FormatWithPositions(ctx context.Context, src string, opts Options) (string, PositionMap, error) {
+	if err := opts.Validate(); err != nil {
+		return "", PositionMap{}, err
+	}
+	f := &formatter{cfg: opts.prettyCfg(), opts: opts, positions: true}
+	res, err := f.formatSource(ctx, src)
+	if err != nil {
+		return "", PositionMap{}, err
+	}
+	// The ends of the input and output always correspond.
+	spans := append(f.spans, span{len(src), len(src), len(res), len(res)})
+	return res, PositionMap{spans: spans, inLen: len(src), outLen: len(res)}, nil
+}
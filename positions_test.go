@@ -0,0 +1,45 @@
+package sqlfmt
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPositionMap(t *testing.T) {
+	src := bom + "select  alpha,beta from tbl;\r\n\r\n-- next\r\nselect 2;\r\n"
+	tests := []struct {
+		name string
+		opts func(*Options)
+	}{
+		{"keep", func(*Options) {}},
+		{"lf", func(o *Options) { o.LineEnding = LineEndingLF }},
+		{"no bom", func(o *Options) { o.BOM = BOMNever }},
+		{"lf no bom", func(o *Options) { o.LineEnding, o.BOM = LineEndingLF, BOMNever }},
+	}
+	// Each of these is in both src and the output once.
+	tokens := []string{"alpha", "beta", "tbl", "-- next", "2;"}
+	for _, tc := range tests {
+		opts := DefaultOptions()
+		tc.opts(&opts)
+		out, m, err := FormatWithPositions(context.Background(), src, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tok := range tokens {
+			in, o := strings.Index(src, tok), strings.Index(out, tok)
+			if got := m.Output(in); got != o {
+				t.Errorf("%s: %q at %d: got output %d, want %d in %q", tc.name, tok, in, got, o, out)
+			}
+			if got := m.Input(o); got != in {
+				t.Errorf("%s: %q at %d of output: got input %d, want %d", tc.name, tok, o, got, in)
+			}
+		}
+		if got := m.Output(len(src)); got != len(out) {
+			t.Errorf("%s: end of input: got output %d, want %d", tc.name, got, len(out))
+		}
+		if got := m.Input(len(out)); got != len(src) {
+			t.Errorf("%s: end of output: got input %d, want %d", tc.name, got, len(src))
+		}
+	}
+}
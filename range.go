@@ -0,0 +1,111 @@
+package sqlfmt
+
+import (
+	"context"
+	"fmt"
+)
+
+// Edit replaces the bytes from Start to End of an input with Text.
+type Edit struct {
+	Start, End int
+	Text       string
+}
+
+// Apply returns src with e applied.
+func (e Edit) Apply(src string) string {
+	return src[:e.Start] + e.Text + src[e.End:]
+}
+
+// FormatRange formats the statements in src that overlap the byte range
+// from start to end, along with the comments before them, and leaves the
+// rest of src unchanged. An empty range selects the statement containing
+// it. Directives before the range apply to it. The returned Edit covers
+// only the formatted statements. If no statement is selected, the Edit is
+// empty.
+func FormatRange(ctx context.Context, src string, start, end int, opts Options) (Edit, error) {
+	if err := opts.Validate(); err != nil {
+		return Edit{}, err
+	}
+	if start < 0 || end > len(src) || start > end {
+		return Edit{}, fmt.Errorf("invalid range %d:%d for input of length %d", start, end, len(src))
+	}
+	e := Edit{Start: -1}
+	// before are the chunks before the range.
+	var before []chunk
+	for pos := 0; pos < len(src); {
+		var c chunk
+		c, pos = nextChunk(src, pos)
+		if c.sql == "" {
+			break
+		}
+		overlaps := c.start < end && start < c.end()
+		if start == end {
+			overlaps = c.start <= start && start <= c.end()
+		}
+		if !overlaps {
+			if e.Start < 0 {
+				before = append(before, c)
+			}
+			continue
+		}
+		if e.Start < 0 {
+			e.Start = c.start
+		}
+		e.End = c.end()
+	}
+	if e.Start < 0 {
+		return Edit{Start: start, End: start}, nil
+	}
+	// The edit is inside src, so use the line ending of all of src and
+	// don't add anything around the statements.
+	if l := opts.layout(src); l.crlf {
+		opts.LineEnding = LineEndingCRLF
+	} else {
+		opts.LineEnding = LineEndingLF
+	}
+	opts.BOM = BOMKeep
+	opts.FinalNewline = FinalNewlineNever
+	// Prepare the chunks before the range so the formatter is in the state
+	// they leave it in, such as after a "sqlfmt: off" directive.
+	f := &formatter{cfg: opts.prettyCfg(), opts: opts}
+	for _, c := range before {
+		if _, err := f.prepare(src, c); err != nil {
+			return Edit{}, err
+		}
+	}
+	text, err := f.formatSource(ctx, src[e.Start:e.End])
+	if err != nil {
+		return Edit{}, rebaseError(err, src, e.Start)
+	}
+	e.Text = text
+	return e, nil
+}
+
+// LineRange returns the byte range of src covering the 1-based, inclusive
+// lines first to last.
+func LineRange(src string, first, last int) (start, end int, err error) {
+	if first < 1 || last < first {
+		return 0, 0, fmt.Errorf("invalid line range %d:%d", first, last)
+	}
+	start, end = -1, len(src)
+	line := 1
+	for i := 0; i <= len(src); i++ {
+		if line == first && start < 0 {
+			start = i
+		}
+		if i == len(src) {
+			break
+		}
+		if src[i] == '\n' {
+			if line == last {
+				end = i
+				break
+			}
+			line++
+		}
+	}
+	if start < 0 {
+		return 0, 0, fmt.Errorf("line %d is past the end of the input", first)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,33 @@
+package sqlfmt
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFormatRangeDirectives(t *testing.T) {
+	src := "-- sqlfmt: off\nselect   1;\nselect   2;\n-- sqlfmt: on\nselect   3;\n"
+	tests := []struct {
+		line int
+		want string
+	}{
+		// The statements after "sqlfmt: off" are left alone even when the
+		// directive is outside the range.
+		{2, src},
+		{3, src},
+		{5, "-- sqlfmt: off\nselect   1;\nselect   2;\n-- sqlfmt: on\nSELECT 3;\n"},
+	}
+	for _, tc := range tests {
+		start, end, err := LineRange(src, tc.line, tc.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := FormatRange(context.Background(), src, start, end, DefaultOptions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := e.Apply(src); got != tc.want {
+			t.Errorf("line %d: got:\n%s\nwant:\n%s", tc.line, got, tc.want)
+		}
+	}
+}
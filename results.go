@@ -0,0 +1,53 @@
+package sqlfmt
+
+import (
+	"context"
+	"strings"
+)
+
+// Result is the result of formatting one statement and the comments before
+// it.
+type Result struct {
+	// Start and End are the byte offsets in the input of the statement,
+	// starting at its first comment.
+	Start, End int
+	// Text is the formatted statement and comments, with LF line endings.
+	// It is the input unchanged if the statement couldn't be formatted.
+	Text string
+	// Tag is the statement tag, such as "SELECT" or "CREATE TABLE", or ""
+	// if the statement wasn't parsed.
+	Tag string
+	// Err is why the statement couldn't be formatted, or nil. It is a
+	// *ParseError or *VerifyError.
+	Err error
+}
+
+// FormatStatements is like Format but returns a result for each statement
+// in src instead of the whole output. Statements that can't be formatted
+// don't stop formatting, and their results have an error. Comments after
+// the last statement have no result.
+func FormatStatements(ctx context.Context, src string, opts Options) ([]Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	f := &formatter{cfg: opts.prettyCfg(), opts: opts, collect: true}
+	if _, err := f.formatSource(ctx, src); err != nil {
+		return nil, err
+	}
+	return f.results, nil
+}
+
+// addResult records the result of j, whose output starts at offset start of
+// out.
+func (f *formatter) addResult(j *job, start int, err error) {
+	if !f.collect || j.c.sql == "" {
+		return
+	}
+	f.results = append(f.results, Result{
+		Start: f.offBase + j.c.start,
+		End:   f.offBase + j.c.end(),
+		Text:  strings.TrimSpace(f.out.String()[start:]),
+		Tag:   j.tag,
+		Err:   err,
+	})
+}
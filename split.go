@@ -0,0 +1,103 @@
+package sqlfmt
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Statement is a statement of SQL input and the comments before it.
+type Statement struct {
+	// Text is the statement, including its semicolon and a comment on the
+	// same line after it. It is empty if the input ends with comments
+	// that aren't followed by a statement.
+	Text string
+	// Start and End are the byte offsets of Text in the input.
+	Start, End int
+	// StartLine and EndLine are the 1-based lines where Text starts and
+	// ends.
+	StartLine, EndLine int
+	// Comments are the comments between the previous statement and this
+	// one.
+	Comments []Comment
+}
+
+// Comment is a comment in SQL input.
+type Comment struct {
+	// Text is the comment, including its delimiters.
+	Text string
+	// Start and End are the byte offsets of Text in the input.
+	Start, End int
+	// Line is the 1-based line where Text starts.
+	Line int
+}
+
+// Split splits src into statements the same way it is split for
+// formatting, without parsing them. Statements are split at semicolons
+// outside of strings, identifiers and comments, and a statement without a
+// semicolon runs to the end of src.
+func Split(src string) []Statement {
+	var stmts []Statement
+	// Offsets in body are base bytes before those in src.
+	body := strings.TrimPrefix(src, bom)
+	base := len(src) - len(body)
+	// line is the 1-based line at offset lineOff of body.
+	line, lineOff := 1, 0
+	lineAt := func(off int) int {
+		line += strings.Count(body[lineOff:off], "\n")
+		lineOff = off
+		return line
+	}
+	for pos := 0; pos < len(body); {
+		var c chunk
+		c, pos = nextChunk(body, pos)
+		if len(c.comments) == 0 && c.sql == "" {
+			continue
+		}
+		var s Statement
+		for _, cm := range c.comments {
+			s.Comments = append(s.Comments, Comment{
+				Text:  cm.text,
+				Start: base + cm.off,
+				End:   base + cm.off + len(cm.text),
+				Line:  lineAt(cm.off),
+			})
+		}
+		s.Text = c.sql
+		if !c.terminated && c.sql != "" {
+			// Leave the comments on the lines after the statement to
+			// the next chunk, as after a semicolon.
+			s.Text = c.sql[:unterminatedLen(c.sql)]
+			pos = c.off + len(s.Text)
+			// The statement includes the whitespace after it.
+			s.Text = strings.TrimRightFunc(s.Text, unicode.IsSpace)
+		}
+		s.Start, s.End = base+c.off, base+c.off+len(s.Text)
+		s.StartLine = lineAt(c.off)
+		s.EndLine = s.StartLine + strings.Count(s.Text, "\n")
+		stmts = append(stmts, s)
+	}
+	return stmts
+}
+
+// unterminatedLen returns the length of sql, a statement without a
+// semicolon, up to its last token and a comment on the same line after it,
+// if only comments follow.
+func unterminatedLen(sql string) int {
+	toks := tokenize(sql)
+	if len(toks) == 0 {
+		return len(sql)
+	}
+	end := toks[len(toks)-1].end
+	end += trailingCommentLen(sql[end:])
+	for rest := sql[end:]; ; {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			return end
+		}
+		n, ok := commentLen(rest)
+		if n == 0 || !ok {
+			return len(sql)
+		}
+		rest = rest[n:]
+	}
+}
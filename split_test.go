@@ -0,0 +1,71 @@
+package sqlfmt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []Statement
+	}{
+		{
+			name: "terminated",
+			src:  "select 1; -- one\n-- two\nselect 2;\n",
+			want: []Statement{
+				{Text: "select 1; -- one", Start: 0, End: 16, StartLine: 1, EndLine: 1},
+				{Text: "select 2;", Start: 24, End: 33, StartLine: 3, EndLine: 3, Comments: []Comment{
+					{Text: "-- two", Start: 17, End: 23, Line: 2},
+				}},
+			},
+		},
+		{
+			name: "unterminated",
+			src:  "select 1;\nselect 2  \n",
+			want: []Statement{
+				{Text: "select 1;", Start: 0, End: 9, StartLine: 1, EndLine: 1},
+				{Text: "select 2", Start: 10, End: 18, StartLine: 2, EndLine: 2},
+			},
+		},
+		{
+			name: "unterminated with comments",
+			src:  "select 2 -- two\n\n/* end */\n-- end\n",
+			want: []Statement{
+				{Text: "select 2 -- two", Start: 0, End: 15, StartLine: 1, EndLine: 1},
+				{Start: 34, End: 34, StartLine: 5, EndLine: 5, Comments: []Comment{
+					{Text: "/* end */", Start: 17, End: 26, Line: 3},
+					{Text: "-- end", Start: 27, End: 33, Line: 4},
+				}},
+			},
+		},
+		{
+			name: "unterminated with inner comment",
+			src:  "select\n-- inner\n2",
+			want: []Statement{
+				{Text: "select\n-- inner\n2", Start: 0, End: 17, StartLine: 1, EndLine: 3},
+			},
+		},
+		{
+			name: "unterminated string",
+			src:  "select 'a\n-- b",
+			want: []Statement{
+				{Text: "select 'a\n-- b", Start: 0, End: 14, StartLine: 1, EndLine: 2},
+			},
+		},
+		{
+			name: "bom",
+			src:  bom + "select 1;\r\nselect 2; /* two */",
+			want: []Statement{
+				{Text: "select 1;", Start: 3, End: 12, StartLine: 1, EndLine: 1},
+				{Text: "select 2; /* two */", Start: 14, End: 33, StartLine: 2, EndLine: 2},
+			},
+		},
+	}
+	for _, tc := range tests {
+		if got := Split(tc.src); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
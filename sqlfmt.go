@@ -1,10 +1,13 @@
 package sqlfmt
 
 import (
+	"context"
+	"fmt"
 	"math/rand"
-	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"unicode"
 
 	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/parser"
@@ -13,57 +16,677 @@ import (
 	"github.com/cockroachdb/cockroachdb-parser/pkg/util/pretty"
 )
 
-var (
-	ignoreComments = regexp.MustCompile(`^--.*\s*`)
-)
-
+// FmtSQL formats stmts using cfg. New code should use Format, which
+// doesn't depend on the parser's configuration type.
 func FmtSQL(cfg tree.PrettyCfg, stmts []string) (string, error) {
-	var prettied strings.Builder
-	for _, stmt := range stmts {
-		for len(stmt) > 0 {
-			stmt = strings.TrimSpace(stmt)
-			hasContent := false
-			// Trim comments, preserving whitespace after them.
-			for {
-				found := ignoreComments.FindString(stmt)
-				if found == "" {
-					break
-				}
-				// Remove trailing whitespace but keep up to 2 newlines.
-				prettied.WriteString(strings.TrimRightFunc(found, unicode.IsSpace))
-				newlines := strings.Count(found, "\n")
-				if newlines > 2 {
-					newlines = 2
-				}
-				prettied.WriteString(strings.Repeat("\n", newlines))
-				stmt = stmt[len(found):]
-				hasContent = true
+	res, _, err := fmtSQL(context.Background(), cfg, cfgOptions(cfg), stmts)
+	return res, err
+}
+
+// fmtSQL formats stmts. Layout is controlled by cfg, and everything else
+// by opts.
+func fmtSQL(ctx context.Context, cfg tree.PrettyCfg, opts Options, stmts []string) (string, []Warning, error) {
+	f := &formatter{cfg: cfg, opts: opts}
+	res, err := f.format(ctx, stmts)
+	if err != nil {
+		return "", nil, err
+	}
+	return res, f.warns, nil
+}
+
+// formatSource formats src, which is a whole input, and returns the output
+// encoded using the layout in opts.
+func (f *formatter) formatSource(ctx context.Context, src string) (string, error) {
+	l := f.opts.layout(src)
+	if strings.HasPrefix(src, bom) {
+		src = src[len(bom):]
+		f.offBase = len(bom)
+	}
+	res, err := f.format(ctx, []string{src})
+	if err != nil {
+		return "", err
+	}
+	return l.encode(res, f.verbatim, f.spans), nil
+}
+
+// format formats stmts and returns the output.
+func (f *formatter) format(ctx context.Context, stmts []string) (string, error) {
+	if f.opts.MaxInputSize > 0 {
+		size := 0
+		for _, s := range stmts {
+			size += len(s)
+		}
+		if size > f.opts.MaxInputSize {
+			return "", &LimitError{Message: fmt.Sprintf("input is %d bytes, limit is %d", size, f.opts.MaxInputSize)}
+		}
+	}
+	if f.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.opts.Timeout)
+		defer cancel()
+	}
+	if ctx.Done() == nil {
+		if err := f.writeAll(ctx, stmts); err != nil {
+			return "", err
+		}
+	} else {
+		// A single statement can take a long time to format, so stop
+		// waiting when ctx is done. The formatting goroutine stops at the
+		// next statement.
+		done := make(chan error, 1)
+		go func() {
+			done <- f.writeAll(ctx, stmts)
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				return "", err
+			}
+		case <-ctx.Done():
+			return "", contextError(ctx)
+		}
+	}
+
+	if len(f.errs) > 0 {
+		return "", f.errs
+	}
+	return strings.TrimRightFunc(f.out.String(), unicode.IsSpace), nil
+}
+
+// writeAll formats stmts using up to opts.Concurrency goroutines.
+func (f *formatter) writeAll(ctx context.Context, stmts []string) error {
+	workers := f.opts.Concurrency
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > 1 {
+		return f.writeParallel(ctx, stmts, workers)
+	}
+	for _, src := range stmts {
+		for pos := 0; pos < len(src); {
+			if ctx.Err() != nil {
+				return contextError(ctx)
 			}
-			// Split by semicolons
-			next := stmt
-			if pos, _ := parser.SplitFirstStatement(stmt); pos > 0 {
-				next = stmt[:pos]
-				stmt = stmt[pos:]
-			} else {
-				stmt = ""
+			var c chunk
+			c, pos = nextChunk(src, pos)
+			var err error
+			if pos, err = f.writeChunk(src, c); err != nil {
+				return err
 			}
-			// This should only return 0 or 1 responses.
-			allParsed, err := parser.Parse(next)
+		}
+	}
+	return nil
+}
+
+// writeParallel formats the chunks of stmts using up to workers goroutines
+// and writes them in order.
+func (f *formatter) writeParallel(ctx context.Context, stmts []string, workers int) error {
+	// Directives affect the chunks after them, so the jobs are prepared in
+	// order before any are formatted.
+	var jobs []*job
+	for _, src := range stmts {
+		for pos := 0; pos < len(src); {
+			var c chunk
+			c, pos = nextChunk(src, pos)
+			j, err := f.prepare(src, c)
 			if err != nil {
-				return "", err
+				return err
+			}
+			jobs = append(jobs, j)
+		}
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+	next := make(chan *job)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range next {
+				j.format()
 			}
-			for _, parsed := range allParsed {
-				prettied.WriteString(cfg.Pretty(parsed.AST))
-				prettied.WriteString(";\n")
-				hasContent = true
+		}()
+	}
+	canceled := false
+	for _, j := range jobs {
+		if canceled = ctx.Err() != nil; canceled {
+			break
+		}
+		next <- j
+	}
+	close(next)
+	wg.Wait()
+	if canceled {
+		return contextError(ctx)
+	}
+	for _, j := range jobs {
+		if _, err := f.finish(j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatter formats chunks of input into out. Layout is controlled by
+// cfg, and everything else by opts.
+type formatter struct {
+	cfg  tree.PrettyCfg
+	opts Options
+	out  strings.Builder
+	// flushed is set once flush has moved output from out.
+	flushed bool
+	// offBase and lineBase are the offset and number of lines of input
+	// before the current source string. colBase is the number of
+	// characters on the current line before the source string.
+	offBase, lineBase, colBase int
+	errs                       ParseErrors
+	warns                      []Warning
+	// idx is the index of the next statement.
+	idx int
+	// disabled is set between "sqlfmt: off" and "sqlfmt: on" directives.
+	disabled bool
+	// verbatim are the ranges of out copied unchanged from the input.
+	verbatim []verbatimRange
+	// positions is set if spans should be recorded.
+	positions bool
+	spans     []span
+	// outLines is the number of lines of output before the first counted
+	// bytes of out.
+	outLines, counted int
+	// collect is set if a result should be recorded for each statement.
+	// Statements that can't be formatted are then copied unchanged.
+	collect bool
+	results []Result
+}
+
+// job is a chunk being formatted. The work that doesn't depend on other
+// chunks is done by format, so jobs can be formatted concurrently.
+type job struct {
+	src string
+	c   chunk
+	// idx is the index of the statement.
+	idx int
+	// head is the output for the comments before the statement.
+	head       string
+	hasContent bool
+	// verbatim is set if the statement is copied unchanged.
+	verbatim bool
+	cfg      tree.PrettyCfg
+	// verify is set if the output should be verified. exactCase is false
+	// if keyword casing isn't deterministic.
+	verify, exactCase bool
+	// positions is set if spans should be recorded. headSpans are the
+	// spans of the comments, with output offsets relative to head.
+	positions bool
+	headSpans []span
+	// headVerbatim are the ranges of head copied unchanged from the input.
+	headVerbatim []verbatimRange
+	// pretty, err and mismatch are set by format. mismatch describes why
+	// the output failed verification. spans are the spans of the tokens
+	// of each pretty statement, with offsets relative to the statement.
+	pretty   []string
+	spans    [][]span
+	err      error
+	mismatch string
+	// overflows are the lines of each pretty statement that are wider
+	// than the line width.
+	overflows [][]Overflow
+	// tag is the statement tag of the first statement.
+	tag string
+	// cache holds formatted statements by key, and may be nil.
+	cache *Cache
+	key   cacheKey
+}
+
+// line returns the 1-based line of input at offset off of src.
+func (f *formatter) line(src string, off int) int {
+	return f.lineBase + strings.Count(src[:off], "\n") + 1
+}
+
+// outputLine returns the 1-based line of output at the end of out.
+func (f *formatter) outputLine() int {
+	s := f.out.String()
+	f.outLines += strings.Count(s[f.counted:], "\n")
+	f.counted = len(s)
+	return f.outLines + 1
+}
+
+// atLineStart reports whether the output is at the start of a line.
+func (f *formatter) atLineStart() bool {
+	if f.out.Len() == 0 {
+		return !f.flushed
+	}
+	s := f.out.String()
+	return s[len(s)-1] == '\n'
+}
+
+// writeChunk formats c, which is from src. It returns the position of src
+// after the chunk and any whitespace that was copied with it.
+func (f *formatter) writeChunk(src string, c chunk) (int, error) {
+	j, err := f.prepare(src, c)
+	if err != nil {
+		return 0, err
+	}
+	j.format()
+	return f.finish(j)
+}
+
+// prepare returns the job for c, which is from src, applying the
+// directives in its comments.
+func (f *formatter) prepare(src string, c chunk) (*job, error) {
+	j := &job{src: src, c: c, idx: f.idx, positions: f.positions}
+	if c.sql != "" {
+		f.idx++
+	}
+	var head strings.Builder
+	// stmtOpts are opts with the changes made by directives before this
+	// statement.
+	stmtOpts := f.opts
+	// Write comments, preserving whitespace after them.
+	for i, cm := range c.comments {
+		switch d, ok := directive(cm.text); {
+		case !ok:
+		case d == "off":
+			f.disabled = true
+		case d == "on":
+			f.disabled = false
+		case !f.disabled:
+			if err := stmtOpts.setDirective(d); err != nil {
+				return nil, &DirectiveError{Line: f.line(src, cm.off), Directive: cm.text, Message: err.Error()}
 			}
-			if hasContent {
-				prettied.WriteString("\n")
+		}
+		j.hasContent = true
+		if f.disabled {
+			n := len(cm.text) + len(cm.space)
+			j.addHeadSpan(cm.off, head.Len(), n)
+			j.headVerbatim = append(j.headVerbatim, verbatimRange{head.Len(), head.Len() + n})
+			head.WriteString(cm.text)
+			head.WriteString(cm.space)
+			continue
+		}
+		// Remove trailing whitespace but keep up to MaxBlankLines blank
+		// lines. A block comment followed by a statement on the same line
+		// gets its own line.
+		text := strings.TrimRightFunc(cm.text, unicode.IsSpace)
+		j.addHeadSpan(cm.off, head.Len(), len(text))
+		head.WriteString(text)
+		newlines := strings.Count(cm.space, "\n")
+		last := i == len(c.comments)-1
+		if newlines > f.opts.MaxBlankLines+1 {
+			newlines = f.opts.MaxBlankLines + 1
+		}
+		if newlines > 1 && last && c.sql != "" && f.opts.CollapseCommentGap {
+			newlines = 1
+		} else if newlines == 0 && (!last || c.sql != "") {
+			newlines = 1
+		}
+		head.WriteString(strings.Repeat("\n", newlines))
+	}
+	j.head = head.String()
+	j.verbatim = f.disabled && c.sql != ""
+	j.cfg = override(f.cfg, f.opts, stmtOpts)
+	j.verify = f.opts.Verify
+	j.exactCase = stmtOpts.Case != CaseSpongeBob
+	if f.opts.Cache != nil && !j.verbatim && c.sql != "" {
+		j.cache = f.opts.Cache
+		j.key = newCacheKey(c.sql, stmtOpts)
+	}
+	f.statementDirectives(src, j)
+	return j, nil
+}
+
+// statementDirectives applies an "off" or "on" directive after the
+// statement of j, which then takes effect from the next statement. Other
+// directives there, and any directive inside the statement, are ignored
+// with a warning.
+func (f *formatter) statementDirectives(src string, j *job) {
+	c := j.c
+	if !strings.Contains(c.sql, directivePrefix) {
+		return
+	}
+	warn := func(text, where string) {
+		f.warns = append(f.warns, Warning{
+			Statement: j.idx,
+			Line:      f.line(src, c.off),
+			Message:   fmt.Sprintf("directive %s %s is ignored", text, where),
+		})
+	}
+	body := c.sql[:len(c.sql)-len(c.trailing)]
+	for _, cm := range findComments(body, tokenize(body)) {
+		if _, ok := directive(cm.text); ok {
+			warn(cm.text, "inside a statement")
+		}
+	}
+	switch d, ok := directive(c.trailing); {
+	case !ok:
+	case d == "off":
+		f.disabled = true
+	case d == "on":
+		f.disabled = false
+	default:
+		warn(c.trailing, "after a statement")
+	}
+}
+
+// format parses and pretty prints the statement of j.
+func (j *job) format() {
+	if j.verbatim || j.c.sql == "" {
+		return
+	}
+	if e, ok := j.cache.get(j.key); ok {
+		j.tag, j.pretty = e.tag, e.pretty
+	} else if !j.parse() {
+		return
+	}
+	for _, p := range j.pretty {
+		j.overflows = append(j.overflows, overflows(p, j.cfg.LineWidth, j.cfg.TabWidth))
+		if j.positions {
+			j.spans = append(j.spans, tokenSpans(j.c.sql, p))
+		}
+	}
+}
+
+// parse parses, pretty prints and verifies the statement of j, and adds it
+// to the cache. It returns false if that failed.
+func (j *job) parse() bool {
+	// This should only return 0 or 1 responses.
+	allParsed, pretty, err := prettySQL(j.cfg, j.c.sql)
+	if err != nil {
+		j.err = err
+		return false
+	}
+	for i, parsed := range allParsed {
+		p := pretty[i]
+		if j.tag == "" {
+			j.tag = parsed.AST.StatementTag()
+		}
+		if j.verify {
+			if j.mismatch = verify(j.cfg, parsed.AST, p, j.exactCase); j.mismatch != "" {
+				return false
 			}
 		}
+		j.pretty = append(j.pretty, p)
+	}
+	j.cache.add(cacheEntry{key: j.key, tag: j.tag, pretty: j.pretty})
+	return true
+}
+
+// addHeadSpan records that n bytes of input at off are copied to head at
+// pos.
+func (j *job) addHeadSpan(off, pos, n int) {
+	if j.positions {
+		j.headSpans = append(j.headSpans, span{off, off + n, pos, pos + n})
+	}
+}
+
+// addSpans records spans, whose offsets are relative to in and out.
+func (f *formatter) addSpans(spans []span, in, out int) {
+	if !f.positions {
+		return
+	}
+	for _, s := range spans {
+		f.spans = append(f.spans, span{
+			inStart:  f.offBase + in + s.inStart,
+			inEnd:    f.offBase + in + s.inEnd,
+			outStart: out + s.outStart,
+			outEnd:   out + s.outEnd,
+		})
+	}
+}
+
+// copyVerbatim copies the statement in c, which is from src, and the
+// whitespace after it to the output unchanged, and records the span of the
+// copied text. It returns the position after the copied whitespace. Unless
+// exact is set, the indentation of the statement's first line is copied
+// too, and the whitespace after it is reduced to its line endings.
+func (f *formatter) copyVerbatim(src string, c chunk, exact bool) int {
+	start := c.off
+	if ls := lineStart(src, c.off); !exact && f.atLineStart() && strings.TrimLeft(src[ls:c.off], " \t") == "" {
+		start = ls
+	}
+	rest := src[c.end():]
+	space := rest[:len(rest)-len(strings.TrimLeftFunc(rest, unicode.IsSpace))]
+	end := c.end() + len(space)
+	n := end - start
+	if i := strings.LastIndexByte(space, '\n'); !exact && i >= 0 {
+		// Leave the indentation of the next line to the next statement,
+		// and drop the spaces at the end of lines.
+		end = c.end() + i + 1
+		space = lineEndings(space)
+		n = c.end() - start
+	}
+	out := f.out.Len()
+	f.out.WriteString(src[start:c.end()])
+	f.out.WriteString(space)
+	f.addSpans([]span{{0, n, 0, n}}, start, out)
+	f.addVerbatim(out, out+n)
+	return end
+}
+
+// addVerbatim records that out[start:end] was copied unchanged from the
+// input.
+func (f *formatter) addVerbatim(start, end int) {
+	if n := len(f.verbatim); n > 0 && f.verbatim[n-1].end == start {
+		f.verbatim[n-1].end = end
+		return
+	}
+	f.verbatim = append(f.verbatim, verbatimRange{start, end})
+}
+
+// lineEndings returns the line endings in s.
+func lineEndings(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\n' {
+			continue
+		}
+		if i > 0 && s[i-1] == '\r' {
+			sb.WriteString("\r\n")
+		} else {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// finish writes the output of j, which has been formatted. It returns the
+// position of j's source after the chunk and any whitespace that was copied
+// with it.
+func (f *formatter) finish(j *job) (int, error) {
+	src, c := j.src, j.c
+	start := f.out.Len()
+	f.addSpans(j.headSpans, 0, start)
+	for _, v := range j.headVerbatim {
+		f.addVerbatim(start+v.start, start+v.end)
+	}
+	f.out.WriteString(j.head)
+	if j.verbatim {
+		end := f.copyVerbatim(src, c, true)
+		f.addResult(j, start, nil)
+		return end, nil
+	}
+	if j.err != nil {
+		perr := newParseError(j.err, src, c.off, c.sql, j.idx)
+		if perr.Line == 1 {
+			perr.Column += f.colBase
+			perr.lineCol = f.colBase
+		}
+		perr.Offset += f.offBase
+		perr.Line += f.lineBase
+		switch {
+		case f.collect:
+			end := f.copyVerbatim(src, c, false)
+			f.addResult(j, start, perr)
+			return end, nil
+		case f.opts.Tolerant:
+			f.warns = append(f.warns, Warning{
+				Statement: j.idx,
+				Line:      f.line(src, c.off),
+				Message:   fmt.Sprintf("statement not formatted: %v", perr),
+			})
+			return f.copyVerbatim(src, c, false), nil
+		case f.opts.AllErrors:
+			f.errs = append(f.errs, perr)
+		default:
+			return 0, perr
+		}
+	}
+	if j.mismatch != "" {
+		err := &VerifyError{
+			Statement: j.idx,
+			Line:      f.line(src, c.off),
+			Message:   j.mismatch,
+		}
+		if !f.collect {
+			return 0, err
+		}
+		end := f.copyVerbatim(src, c, false)
+		f.addResult(j, start, err)
+		return end, nil
+	}
+	// An unformatted statement may have been followed by another on the
+	// same line.
+	if len(j.pretty) > 0 && !f.atLineStart() {
+		f.out.WriteString("\n")
+	}
+	for i, p := range j.pretty {
+		if j.positions {
+			f.addSpans(j.spans[i], c.off, f.out.Len())
+		}
+		if len(j.overflows[i]) > 0 {
+			f.warnOverflows(j, i, f.outputLine())
+		}
+		f.out.WriteString(p)
+		f.out.WriteString("\n")
+	}
+	f.addResult(j, start, nil)
+	if j.hasContent || len(j.pretty) > 0 {
+		blank := 1
+		if f.opts.KeepBlankLines {
+			blank = blankLines(src[c.end():])
+		}
+		if blank > f.opts.MaxBlankLines {
+			blank = f.opts.MaxBlankLines
+		}
+		f.out.WriteString(strings.Repeat("\n", blank))
+	}
+	return c.end(), nil
+}
+
+// warnOverflows adds a warning for each line of the ith pretty statement of
+// j that is wider than the line width. line is the line of output where the
+// statement starts.
+func (f *formatter) warnOverflows(j *job, i, line int) {
+	for _, o := range j.overflows[i] {
+		o := o
+		o.Line += line
+		f.warns = append(f.warns, Warning{
+			Statement: j.idx,
+			Line:      f.line(j.src, j.c.off),
+			Message:   fmt.Sprintf("line %d of output is %d columns wide, over the line width of %d, at %q", o.Line, o.Width, j.cfg.LineWidth, o.Token),
+			Overflow:  &o,
+		})
+	}
+}
+
+// blankLines returns the number of blank lines at the start of s, not
+// counting the end of the current line.
+func blankLines(s string) int {
+	space := s[:len(s)-len(strings.TrimLeftFunc(s, unicode.IsSpace))]
+	if n := strings.Count(space, "\n"); n > 1 {
+		return n - 1
+	}
+	return 0
+}
+
+// chunk is a statement in the input along with the comments before it.
+type chunk struct {
+	// start is the offset of the first comment, or of the statement if
+	// there are no comments.
+	start int
+	// comments are the comments before the statement.
+	comments []leadingComment
+	// off is the offset of sql.
+	off int
+	// sql is the statement, including its semicolon and a comment on the
+	// same line after it. It is empty if the input ends with comments.
+	sql string
+	// terminated is set if the statement ends with a semicolon.
+	terminated bool
+	// trailing is the comment after the semicolon, which ends sql.
+	trailing string
+}
+
+// leadingComment is a comment before a statement.
+type leadingComment struct {
+	// off is the offset of text.
+	off  int
+	text string
+	// space is the whitespace after the comment.
+	space string
+}
+
+// end returns the offset of the end of c.
+func (c chunk) end() int {
+	return c.off + len(c.sql)
+}
+
+// nextChunk returns the chunk of src starting at pos and the position after
+// it. Whitespace before the chunk is skipped.
+func nextChunk(src string, pos int) (chunk, int) {
+	stmt := strings.TrimLeftFunc(src[pos:], unicode.IsSpace)
+	c := chunk{start: len(src) - len(stmt)}
+	for {
+		n, ok := commentLen(stmt)
+		if n == 0 || !ok {
+			// Leave unterminated comments to the parser, which will
+			// report them.
+			break
+		}
+		space := stmt[n:]
+		space = space[:len(space)-len(strings.TrimLeftFunc(space, unicode.IsSpace))]
+		c.comments = append(c.comments, leadingComment{
+			off:   len(src) - len(stmt),
+			text:  stmt[:n],
+			space: space,
+		})
+		stmt = stmt[n+len(space):]
+	}
+	// Split by semicolons
+	c.off = len(src) - len(stmt)
+	next := stmt
+	if pos := splitFirst(stmt); pos > 0 {
+		c.terminated = true
+		next = stmt[:pos]
+		// Keep a comment on the same line as the semicolon with this
+		// statement.
+		n := trailingCommentLen(stmt[pos:])
+		next += stmt[pos : pos+n]
+		c.trailing = strings.TrimLeft(stmt[pos:pos+n], " \t")
 	}
+	c.sql = next
+	return c, c.end()
+}
+
+// splitWindow is the initial amount of input splitFirst scans.
+const splitWindow = 4 << 10
 
-	return strings.TrimRightFunc(prettied.String(), unicode.IsSpace), nil
+// splitFirst returns the position after the semicolon ending the first
+// statement in s, or 0 if there is none. The scanner allocates a buffer the
+// size of its input, so s is scanned in growing windows to keep splitting a
+// large input linear. A semicolon found in a window is always correct
+// because only the token cut off at the end of the window can differ from
+// scanning all of s.
+func splitFirst(s string) int {
+	for n := splitWindow; n < len(s); n *= 2 {
+		if pos, ok := parser.SplitFirstStatement(s[:n]); ok {
+			return pos
+		}
+	}
+	pos, _ := parser.SplitFirstStatement(s)
+	return pos
 }
 
 func parseBool(val string) (bool, error) {
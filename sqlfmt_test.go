@@ -0,0 +1,103 @@
+package sqlfmt
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTolerantVerbatim(t *testing.T) {
+	bad := "    do $$ begin\n      perform 1;\n    end $$ bogus;"
+	src := "select 1;\n" + bad + "  \nselec 3; \n  select   4;\n"
+	want := "SELECT 1;\n\n" + bad + "\nselec 3;\nSELECT 4;\n"
+	opts := DefaultOptions()
+	opts.Tolerant = true
+	out, warns, err := FormatWithWarnings(context.Background(), src, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warns) != 2 {
+		t.Errorf("got %d warnings, want 2", len(warns))
+	}
+	if out != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out, want)
+	}
+	if !strings.Contains(out, bad+"\n") {
+		t.Fatalf("statement not copied unchanged:\n%s", out)
+	}
+	again, err := Format(context.Background(), out, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != out {
+		t.Fatalf("formatting isn't idempotent:\n%s\nformatted again:\n%s", out, again)
+	}
+}
+
+func BenchmarkFormat(b *testing.B) {
+	src, err := os.ReadFile("testdata/bench.sql")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, bc := range []struct {
+		name        string
+		concurrency int
+	}{
+		{"serial", 1},
+		{"parallel", 0},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			opts := DefaultOptions()
+			opts.Concurrency = bc.concurrency
+			b.SetBytes(int64(len(src)))
+			for i := 0; i < b.N; i++ {
+				if _, err := Format(context.Background(), string(src), opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestStatementDirectives(t *testing.T) {
+	tests := []struct {
+		src, want string
+		warns     int
+	}{
+		{
+			src:  "-- sqlfmt: off\nselect   1; -- sqlfmt: on\nselect   2;\n",
+			want: "-- sqlfmt: off\nselect   1; -- sqlfmt: on\nSELECT 2;\n",
+		},
+		{
+			src:  "-- sqlfmt: off\nselect   1; /* sqlfmt: on */\nselect   2;\n",
+			want: "-- sqlfmt: off\nselect   1; /* sqlfmt: on */\nSELECT 2;\n",
+		},
+		{
+			src:  "select   1; -- sqlfmt: off\nselect   2;\n",
+			want: "SELECT 1; -- sqlfmt: off\n\nselect   2;\n",
+		},
+		{
+			src:   "select /* sqlfmt: off */ 1;\nselect   2;\n",
+			want:  "SELECT /* sqlfmt: off */ 1;\n\nSELECT 2;\n",
+			warns: 1,
+		},
+		{
+			src:   "select   1; -- sqlfmt: casemode=lower\nselect   2;\n",
+			want:  "SELECT 1; -- sqlfmt: casemode=lower\n\nSELECT 2;\n",
+			warns: 1,
+		},
+	}
+	for _, tc := range tests {
+		out, warns, err := FormatWithWarnings(context.Background(), tc.src, DefaultOptions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != tc.want {
+			t.Errorf("%q:\ngot:\n%s\nwant:\n%s", tc.src, out, tc.want)
+		}
+		if len(warns) != tc.warns {
+			t.Errorf("%q: got warnings %v, want %d", tc.src, warns, tc.warns)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package sqlfmt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minStreamRead is the smallest read FormatStream makes.
+const minStreamRead = 64 << 10
+
+// FormatStream is like FormatWithWarnings but reads the statements from r
+// and writes them to w as they are formatted. The line ending and byte
+// order mark are detected from the first line of input.
+// Input is only held in memory until the statement containing it has been
+// formatted, so very large inputs can be formatted. If an error occurs,
+// the statements before it have already been written. Limits are only
+// checked between statements.
+func FormatStream(ctx context.Context, w io.Writer, r io.Reader, opts Options) ([]Warning, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	f := formatter{cfg: opts.prettyCfg(), opts: opts}
+	// buf holds the input read so far from start on, which hasn't been
+	// formatted. src is the part of it that was split into the chunk c, and
+	// next is the position in src after c. Until a statement is
+	// terminated, only reading a semicolon can change its chunk, so buf is
+	// only copied to src and split again then, or when the first line
+	// ends.
+	var buf []byte
+	start := 0
+	src := ""
+	var c chunk
+	next, split := 0, true
+	eof := false
+	// l is the layout of the output, which is detected from the first
+	// line of input. endsWithNewline is set if the input read so far ends
+	// with a newline.
+	var l layout
+	detected, endsWithNewline := false, false
+	for {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
+		if !detected && (eof || strings.IndexByte(src, '\n') >= 0) {
+			l = opts.layout(src)
+			if strings.HasPrefix(src, bom) {
+				src = src[len(bom):]
+				start += len(bom)
+				f.offBase += len(bom)
+			}
+			detected, split = true, true
+		}
+		if eof && strings.TrimLeftFunc(src, unicode.IsSpace) == "" {
+			break
+		}
+		if split {
+			c, next = nextChunk(src, 0)
+			split = false
+		}
+		if !eof && (!detected || !chunkDone(src[next:])) {
+			// Move the unformatted input to the start of buf so it
+			// doesn't keep growing.
+			if start > 0 {
+				buf = buf[:copy(buf, buf[start:])]
+				start = 0
+			}
+			if len(buf) == cap(buf) || cap(buf)-len(buf) < minStreamRead/2 {
+				buf = append(buf, make([]byte, len(buf)+minStreamRead)...)[:len(buf)]
+			}
+			n, err := r.Read(buf[len(buf):cap(buf)])
+			read := buf[len(buf) : len(buf)+n]
+			buf = buf[:len(buf)+n]
+			if n > 0 {
+				endsWithNewline = buf[len(buf)-1] == '\n'
+			}
+			if size := f.offBase + len(buf); opts.MaxInputSize > 0 && size > opts.MaxInputSize {
+				return nil, &LimitError{Message: fmt.Sprintf("input is over %d bytes", opts.MaxInputSize)}
+			}
+			if err == io.EOF {
+				eof = true
+			} else if err != nil {
+				return nil, err
+			}
+			if eof || c.terminated || bytes.IndexByte(read, ';') >= 0 || !detected && bytes.IndexByte(read, '\n') >= 0 {
+				src = string(buf)
+				split = true
+			}
+			continue
+		}
+		end, err := f.writeChunk(src, c)
+		if err != nil {
+			return nil, err
+		}
+		if err := f.flush(w, l); err != nil {
+			return nil, err
+		}
+		// Discard the formatted input.
+		done := src[:end]
+		if i := strings.LastIndexByte(done, '\n'); i >= 0 {
+			f.colBase = utf8.RuneCountInString(done[i+1:])
+		} else {
+			f.colBase += utf8.RuneCountInString(done)
+		}
+		f.lineBase += strings.Count(done, "\n")
+		f.offBase += end
+		src = src[end:]
+		start += end
+		split = true
+	}
+	if opts.FinalNewline == FinalNewlineKeep {
+		l.finalNewline = endsWithNewline
+	}
+	if f.flushed && l.finalNewline {
+		if _, err := io.WriteString(w, l.eol()); err != nil {
+			return nil, err
+		}
+	}
+	if len(f.errs) > 0 {
+		return nil, f.errs
+	}
+	return f.warns, nil
+}
+
+// flush writes out to w using l, except for trailing whitespace, which is
+// kept in out in case it is followed by more output.
+func (f *formatter) flush(w io.Writer, l layout) error {
+	s := f.out.String()
+	t := strings.TrimRightFunc(s, unicode.IsSpace)
+	if t == "" {
+		return nil
+	}
+	out, _ := l.convert(t, f.verbatim)
+	if !f.flushed && l.bom {
+		out = bom + out
+	}
+	if _, err := io.WriteString(w, out); err != nil {
+		return err
+	}
+	f.outputLine()
+	f.out.Reset()
+	f.out.WriteString(s[len(t):])
+	// Keep the ranges in the whitespace left in out.
+	var verbatim []verbatimRange
+	for _, v := range f.verbatim {
+		if v.end > len(t) {
+			if v.start < len(t) {
+				v.start = len(t)
+			}
+			verbatim = append(verbatim, verbatimRange{v.start - len(t), v.end - len(t)})
+		}
+	}
+	f.verbatim = verbatim
+	f.counted = f.out.Len()
+	f.flushed = true
+	return nil
+}
+
+// chunkDone reports whether a chunk is complete when rest is the input read
+// after it, so reading more input can't change it.
+func chunkDone(rest string) bool {
+	// Until the next line has started, more of the statement, a comment
+	// after it or whitespace could still be read. That includes the
+	// second dash of a line comment after a lone "-".
+	if strings.IndexByte(rest, '\n') < 0 || strings.TrimLeftFunc(rest, unicode.IsSpace) == "" {
+		return false
+	}
+	// A block comment starting on the line of the semicolon is part of the
+	// chunk once it's terminated, even if it spans lines.
+	_, ok := commentLen(strings.TrimLeft(rest, " \t"))
+	return ok
+}
@@ -0,0 +1,46 @@
+package sqlfmt
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var streamInputs = []string{
+	"select 1; /* about the\nnext */ select 2;\n",
+	"select 3; -- three\nselect 4;\n",
+	"select 1;\n\n\n-- before two\n\nselect 2; select 3;",
+	"\uFEFFselect 1;\r\nselect 'a;b'; /* c */\r\n",
+	"select 1; /* nested /* comment */ */\nselect 2; -- end",
+	"select 1;\r\nselect 2;\r\n-- sqlfmt: off\r\nselect   3,\n  4;\r\n-- sqlfmt: on\r\nselect 5;\r\n",
+	"select 1;\n-- only a comment\n",
+}
+
+func formatStream(t *testing.T, r io.Reader) string {
+	t.Helper()
+	var sb strings.Builder
+	if _, err := FormatStream(context.Background(), &sb, r, DefaultOptions()); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+func TestFormatStreamReads(t *testing.T) {
+	for _, src := range streamInputs {
+		want, err := Format(context.Background(), src, DefaultOptions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := formatStream(t, iotest.OneByteReader(strings.NewReader(src))); got != want {
+			t.Errorf("%q read one byte at a time:\ngot:\n%s\nwant:\n%s", src, got, want)
+		}
+		for n := 0; n <= len(src); n++ {
+			r := io.MultiReader(strings.NewReader(src[:n]), strings.NewReader(src[n:]))
+			if got := formatStream(t, r); got != want {
+				t.Errorf("%q read at %d:\ngot:\n%s\nwant:\n%s", src, n, got, want)
+			}
+		}
+	}
+}
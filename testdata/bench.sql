@@ -0,0 +1,863 @@
+update accounts set balance = balance - 9326, updated_at = now() where id = 868 and balance >= 9326;
+
+insert into orders (id, user_id, total, status) values (4180, 121, 4180.121, 'pending'), (121, 4180, 1.5, 'paid');
+
+select case when x > 7365 then 'big' when x > 484 then 'medium' else 'small' end as size, coalesce(y, 0) + 484 from measurements where z between 484 and 7365;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 3440 group by kind;
+
+select case when x > 465 then 'big' when x > 915 then 'medium' else 'small' end as size, coalesce(y, 0) + 915 from measurements where z between 915 and 465;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 7091 group by kind;
+
+select id, name, email, created_at from users where id = 7298 and deleted_at is null order by created_at desc limit 10;
+
+delete from sessions where user_id = 9686 and expires_at < now() - interval '968 days';
+
+insert into orders (id, user_id, total, status) values (5201, 32, 5201.32, 'pending'), (32, 5201, 1.5, 'paid');
+
+select id, name, email, created_at from users where id = 417 and deleted_at is null order by created_at desc limit 10;
+
+select id, name, email, created_at from users where id = 6246 and deleted_at is null order by created_at desc limit 10;
+
+delete from sessions where user_id = 6916 and expires_at < now() - interval '744 days';
+
+select id, name, email, created_at from users where id = 8645 and deleted_at is null order by created_at desc limit 10;
+
+select case when x > 8124 then 'big' when x > 567 then 'medium' else 'small' end as size, coalesce(y, 0) + 567 from measurements where z between 567 and 8124;
+
+delete from sessions where user_id = 5664 and expires_at < now() - interval '237 days';
+
+delete from sessions where user_id = 7531 and expires_at < now() - interval '976 days';
+
+-- report 353
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 427 order by total desc;
+
+insert into orders (id, user_id, total, status) values (3046, 645, 3046.645, 'pending'), (645, 3046, 1.5, 'paid');
+
+-- report 1981
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 761 order by total desc;
+
+create table t8206 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 8319 group by kind;
+
+delete from sessions where user_id = 4971 and expires_at < now() - interval '291 days';
+
+select case when x > 8279 then 'big' when x > 403 then 'medium' else 'small' end as size, coalesce(y, 0) + 403 from measurements where z between 403 and 8279;
+
+select id, name, email, created_at from users where id = 7869 and deleted_at is null order by created_at desc limit 10;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 6789 group by kind;
+
+update accounts set balance = balance - 6015, updated_at = now() where id = 562 and balance >= 6015;
+
+create table t1417 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+insert into orders (id, user_id, total, status) values (2683, 534, 2683.534, 'pending'), (534, 2683, 1.5, 'paid');
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 6071 group by kind;
+
+select id, name, email, created_at from users where id = 7690 and deleted_at is null order by created_at desc limit 10;
+
+-- report 9719
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 593 order by total desc;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 2792 group by kind;
+
+delete from sessions where user_id = 202 and expires_at < now() - interval '790 days';
+
+delete from sessions where user_id = 8842 and expires_at < now() - interval '943 days';
+
+delete from sessions where user_id = 6627 and expires_at < now() - interval '527 days';
+
+create table t9467 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+select case when x > 4412 then 'big' when x > 676 then 'medium' else 'small' end as size, coalesce(y, 0) + 676 from measurements where z between 676 and 4412;
+
+select id, name, email, created_at from users where id = 6287 and deleted_at is null order by created_at desc limit 10;
+
+update accounts set balance = balance - 8499, updated_at = now() where id = 797 and balance >= 8499;
+
+delete from sessions where user_id = 6982 and expires_at < now() - interval '973 days';
+
+select id, name, email, created_at from users where id = 7883 and deleted_at is null order by created_at desc limit 10;
+
+create table t9339 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+delete from sessions where user_id = 8270 and expires_at < now() - interval '424 days';
+
+select case when x > 5846 then 'big' when x > 425 then 'medium' else 'small' end as size, coalesce(y, 0) + 425 from measurements where z between 425 and 5846;
+
+create table t26 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+create table t7507 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+select id, name, email, created_at from users where id = 3762 and deleted_at is null order by created_at desc limit 10;
+
+update accounts set balance = balance - 9024, updated_at = now() where id = 599 and balance >= 9024;
+
+update accounts set balance = balance - 1501, updated_at = now() where id = 818 and balance >= 1501;
+
+-- report 532
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 862 order by total desc;
+
+insert into orders (id, user_id, total, status) values (1364, 889, 1364.889, 'pending'), (889, 1364, 1.5, 'paid');
+
+select id, name, email, created_at from users where id = 7422 and deleted_at is null order by created_at desc limit 10;
+
+-- report 4089
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 276 order by total desc;
+
+insert into orders (id, user_id, total, status) values (3025, 353, 3025.353, 'pending'), (353, 3025, 1.5, 'paid');
+
+-- report 1139
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 172 order by total desc;
+
+update accounts set balance = balance - 4182, updated_at = now() where id = 541 and balance >= 4182;
+
+update accounts set balance = balance - 4472, updated_at = now() where id = 664 and balance >= 4472;
+
+-- report 7450
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 720 order by total desc;
+
+create table t8135 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+insert into orders (id, user_id, total, status) values (388, 320, 388.320, 'pending'), (320, 388, 1.5, 'paid');
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 5626 group by kind;
+
+delete from sessions where user_id = 4234 and expires_at < now() - interval '112 days';
+
+-- report 8358
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 215 order by total desc;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 342 group by kind;
+
+select id, name, email, created_at from users where id = 6510 and deleted_at is null order by created_at desc limit 10;
+
+select id, name, email, created_at from users where id = 2626 and deleted_at is null order by created_at desc limit 10;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 8925 group by kind;
+
+delete from sessions where user_id = 8464 and expires_at < now() - interval '462 days';
+
+delete from sessions where user_id = 8584 and expires_at < now() - interval '665 days';
+
+select id, name, email, created_at from users where id = 6471 and deleted_at is null order by created_at desc limit 10;
+
+create table t6985 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+-- report 2060
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 992 order by total desc;
+
+delete from sessions where user_id = 778 and expires_at < now() - interval '314 days';
+
+insert into orders (id, user_id, total, status) values (1253, 318, 1253.318, 'pending'), (318, 1253, 1.5, 'paid');
+
+-- report 2593
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 427 order by total desc;
+
+-- report 2137
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 9 order by total desc;
+
+select id, name, email, created_at from users where id = 9677 and deleted_at is null order by created_at desc limit 10;
+
+delete from sessions where user_id = 9344 and expires_at < now() - interval '472 days';
+
+update accounts set balance = balance - 8338, updated_at = now() where id = 39 and balance >= 8338;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 3284 group by kind;
+
+insert into orders (id, user_id, total, status) values (3372, 588, 3372.588, 'pending'), (588, 3372, 1.5, 'paid');
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 9690 group by kind;
+
+select case when x > 1711 then 'big' when x > 961 then 'medium' else 'small' end as size, coalesce(y, 0) + 961 from measurements where z between 961 and 1711;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 4851 group by kind;
+
+select case when x > 282 then 'big' when x > 334 then 'medium' else 'small' end as size, coalesce(y, 0) + 334 from measurements where z between 334 and 282;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 4610 group by kind;
+
+update accounts set balance = balance - 3291, updated_at = now() where id = 879 and balance >= 3291;
+
+create table t9230 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+update accounts set balance = balance - 5556, updated_at = now() where id = 440 and balance >= 5556;
+
+delete from sessions where user_id = 4367 and expires_at < now() - interval '691 days';
+
+insert into orders (id, user_id, total, status) values (6214, 955, 6214.955, 'pending'), (955, 6214, 1.5, 'paid');
+
+create table t8755 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+delete from sessions where user_id = 1071 and expires_at < now() - interval '743 days';
+
+select id, name, email, created_at from users where id = 1388 and deleted_at is null order by created_at desc limit 10;
+
+update accounts set balance = balance - 2729, updated_at = now() where id = 933 and balance >= 2729;
+
+delete from sessions where user_id = 4392 and expires_at < now() - interval '778 days';
+
+create table t9834 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+-- report 6032
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 347 order by total desc;
+
+create table t1867 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+delete from sessions where user_id = 9896 and expires_at < now() - interval '799 days';
+
+select case when x > 2218 then 'big' when x > 594 then 'medium' else 'small' end as size, coalesce(y, 0) + 594 from measurements where z between 594 and 2218;
+
+insert into orders (id, user_id, total, status) values (5255, 41, 5255.41, 'pending'), (41, 5255, 1.5, 'paid');
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 1200 group by kind;
+
+update accounts set balance = balance - 2049, updated_at = now() where id = 350 and balance >= 2049;
+
+insert into orders (id, user_id, total, status) values (9625, 801, 9625.801, 'pending'), (801, 9625, 1.5, 'paid');
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 1256 group by kind;
+
+delete from sessions where user_id = 9273 and expires_at < now() - interval '84 days';
+
+-- report 5979
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 913 order by total desc;
+
+-- report 9248
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 548 order by total desc;
+
+insert into orders (id, user_id, total, status) values (7501, 919, 7501.919, 'pending'), (919, 7501, 1.5, 'paid');
+
+-- report 1766
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 806 order by total desc;
+
+select id, name, email, created_at from users where id = 4846 and deleted_at is null order by created_at desc limit 10;
+
+select id, name, email, created_at from users where id = 1503 and deleted_at is null order by created_at desc limit 10;
+
+insert into orders (id, user_id, total, status) values (656, 193, 656.193, 'pending'), (193, 656, 1.5, 'paid');
+
+delete from sessions where user_id = 9615 and expires_at < now() - interval '432 days';
+
+update accounts set balance = balance - 1894, updated_at = now() where id = 462 and balance >= 1894;
+
+update accounts set balance = balance - 3956, updated_at = now() where id = 163 and balance >= 3956;
+
+insert into orders (id, user_id, total, status) values (7129, 933, 7129.933, 'pending'), (933, 7129, 1.5, 'paid');
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 8896 group by kind;
+
+-- report 9015
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 260 order by total desc;
+
+select case when x > 5153 then 'big' when x > 103 then 'medium' else 'small' end as size, coalesce(y, 0) + 103 from measurements where z between 103 and 5153;
+
+delete from sessions where user_id = 5201 and expires_at < now() - interval '41 days';
+
+select id, name, email, created_at from users where id = 173 and deleted_at is null order by created_at desc limit 10;
+
+-- report 9775
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 328 order by total desc;
+
+select case when x > 6411 then 'big' when x > 321 then 'medium' else 'small' end as size, coalesce(y, 0) + 321 from measurements where z between 321 and 6411;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 1032 group by kind;
+
+create table t9855 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+select case when x > 1825 then 'big' when x > 257 then 'medium' else 'small' end as size, coalesce(y, 0) + 257 from measurements where z between 257 and 1825;
+
+delete from sessions where user_id = 8896 and expires_at < now() - interval '889 days';
+
+select case when x > 5830 then 'big' when x > 266 then 'medium' else 'small' end as size, coalesce(y, 0) + 266 from measurements where z between 266 and 5830;
+
+update accounts set balance = balance - 8874, updated_at = now() where id = 213 and balance >= 8874;
+
+-- report 3264
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 253 order by total desc;
+
+create table t1334 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+-- report 1465
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 772 order by total desc;
+
+select case when x > 1483 then 'big' when x > 668 then 'medium' else 'small' end as size, coalesce(y, 0) + 668 from measurements where z between 668 and 1483;
+
+create table t3727 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+-- report 673
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 336 order by total desc;
+
+update accounts set balance = balance - 5190, updated_at = now() where id = 812 and balance >= 5190;
+
+-- report 4028
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 343 order by total desc;
+
+insert into orders (id, user_id, total, status) values (8917, 627, 8917.627, 'pending'), (627, 8917, 1.5, 'paid');
+
+insert into orders (id, user_id, total, status) values (4016, 226, 4016.226, 'pending'), (226, 4016, 1.5, 'paid');
+
+select id, name, email, created_at from users where id = 3994 and deleted_at is null order by created_at desc limit 10;
+
+insert into orders (id, user_id, total, status) values (4392, 565, 4392.565, 'pending'), (565, 4392, 1.5, 'paid');
+
+insert into orders (id, user_id, total, status) values (1231, 23, 1231.23, 'pending'), (23, 1231, 1.5, 'paid');
+
+select id, name, email, created_at from users where id = 4765 and deleted_at is null order by created_at desc limit 10;
+
+create table t8082 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+update accounts set balance = balance - 1654, updated_at = now() where id = 514 and balance >= 1654;
+
+create table t1264 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+update accounts set balance = balance - 2943, updated_at = now() where id = 795 and balance >= 2943;
+
+update accounts set balance = balance - 2319, updated_at = now() where id = 842 and balance >= 2319;
+
+create table t5008 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+-- report 2070
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 916 order by total desc;
+
+delete from sessions where user_id = 2322 and expires_at < now() - interval '559 days';
+
+select id, name, email, created_at from users where id = 5179 and deleted_at is null order by created_at desc limit 10;
+
+delete from sessions where user_id = 2919 and expires_at < now() - interval '307 days';
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 8807 group by kind;
+
+select id, name, email, created_at from users where id = 4052 and deleted_at is null order by created_at desc limit 10;
+
+insert into orders (id, user_id, total, status) values (7319, 828, 7319.828, 'pending'), (828, 7319, 1.5, 'paid');
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 9000 group by kind;
+
+select case when x > 8816 then 'big' when x > 465 then 'medium' else 'small' end as size, coalesce(y, 0) + 465 from measurements where z between 465 and 8816;
+
+select id, name, email, created_at from users where id = 6484 and deleted_at is null order by created_at desc limit 10;
+
+create table t2811 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+select case when x > 400 then 'big' when x > 813 then 'medium' else 'small' end as size, coalesce(y, 0) + 813 from measurements where z between 813 and 400;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 9349 group by kind;
+
+select id, name, email, created_at from users where id = 5816 and deleted_at is null order by created_at desc limit 10;
+
+update accounts set balance = balance - 9725, updated_at = now() where id = 129 and balance >= 9725;
+
+update accounts set balance = balance - 4246, updated_at = now() where id = 849 and balance >= 4246;
+
+-- report 6518
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 578 order by total desc;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 2821 group by kind;
+
+insert into orders (id, user_id, total, status) values (3827, 498, 3827.498, 'pending'), (498, 3827, 1.5, 'paid');
+
+select id, name, email, created_at from users where id = 2910 and deleted_at is null order by created_at desc limit 10;
+
+create table t8207 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+select case when x > 3699 then 'big' when x > 245 then 'medium' else 'small' end as size, coalesce(y, 0) + 245 from measurements where z between 245 and 3699;
+
+create table t8112 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+select case when x > 3688 then 'big' when x > 730 then 'medium' else 'small' end as size, coalesce(y, 0) + 730 from measurements where z between 730 and 3688;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 5521 group by kind;
+
+-- report 3596
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 50 order by total desc;
+
+insert into orders (id, user_id, total, status) values (8384, 661, 8384.661, 'pending'), (661, 8384, 1.5, 'paid');
+
+create table t2613 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+delete from sessions where user_id = 5109 and expires_at < now() - interval '306 days';
+
+-- report 9050
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 381 order by total desc;
+
+update accounts set balance = balance - 7615, updated_at = now() where id = 609 and balance >= 7615;
+
+insert into orders (id, user_id, total, status) values (2020, 919, 2020.919, 'pending'), (919, 2020, 1.5, 'paid');
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 2889 group by kind;
+
+-- report 6992
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 223 order by total desc;
+
+select id, name, email, created_at from users where id = 8111 and deleted_at is null order by created_at desc limit 10;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 5702 group by kind;
+
+update accounts set balance = balance - 8917, updated_at = now() where id = 748 and balance >= 8917;
+
+select id, name, email, created_at from users where id = 8589 and deleted_at is null order by created_at desc limit 10;
+
+-- report 1656
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 274 order by total desc;
+
+insert into orders (id, user_id, total, status) values (2280, 993, 2280.993, 'pending'), (993, 2280, 1.5, 'paid');
+
+insert into orders (id, user_id, total, status) values (7292, 872, 7292.872, 'pending'), (872, 7292, 1.5, 'paid');
+
+delete from sessions where user_id = 6265 and expires_at < now() - interval '963 days';
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 6509 group by kind;
+
+create table t7179 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+select case when x > 3474 then 'big' when x > 123 then 'medium' else 'small' end as size, coalesce(y, 0) + 123 from measurements where z between 123 and 3474;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 9842 group by kind;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 1935 group by kind;
+
+-- report 4550
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 255 order by total desc;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 9165 group by kind;
+
+delete from sessions where user_id = 8657 and expires_at < now() - interval '450 days';
+
+select id, name, email, created_at from users where id = 505 and deleted_at is null order by created_at desc limit 10;
+
+delete from sessions where user_id = 4267 and expires_at < now() - interval '212 days';
+
+update accounts set balance = balance - 4666, updated_at = now() where id = 152 and balance >= 4666;
+
+delete from sessions where user_id = 4477 and expires_at < now() - interval '319 days';
+
+-- report 7314
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 811 order by total desc;
+
+update accounts set balance = balance - 8936, updated_at = now() where id = 366 and balance >= 8936;
+
+select case when x > 6881 then 'big' when x > 877 then 'medium' else 'small' end as size, coalesce(y, 0) + 877 from measurements where z between 877 and 6881;
+
+insert into orders (id, user_id, total, status) values (3424, 585, 3424.585, 'pending'), (585, 3424, 1.5, 'paid');
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 3356 group by kind;
+
+insert into orders (id, user_id, total, status) values (396, 121, 396.121, 'pending'), (121, 396, 1.5, 'paid');
+
+select id, name, email, created_at from users where id = 8934 and deleted_at is null order by created_at desc limit 10;
+
+update accounts set balance = balance - 1232, updated_at = now() where id = 513 and balance >= 1232;
+
+create table t9382 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+-- report 7163
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 516 order by total desc;
+
+create table t8658 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+select id, name, email, created_at from users where id = 2030 and deleted_at is null order by created_at desc limit 10;
+
+select case when x > 5738 then 'big' when x > 313 then 'medium' else 'small' end as size, coalesce(y, 0) + 313 from measurements where z between 313 and 5738;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 5561 group by kind;
+
+select case when x > 1853 then 'big' when x > 664 then 'medium' else 'small' end as size, coalesce(y, 0) + 664 from measurements where z between 664 and 1853;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 6266 group by kind;
+
+select id, name, email, created_at from users where id = 4549 and deleted_at is null order by created_at desc limit 10;
+
+delete from sessions where user_id = 7563 and expires_at < now() - interval '616 days';
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 5003 group by kind;
+
+update accounts set balance = balance - 7363, updated_at = now() where id = 635 and balance >= 7363;
+
+delete from sessions where user_id = 5889 and expires_at < now() - interval '539 days';
+
+select id, name, email, created_at from users where id = 6377 and deleted_at is null order by created_at desc limit 10;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 6640 group by kind;
+
+insert into orders (id, user_id, total, status) values (8073, 764, 8073.764, 'pending'), (764, 8073, 1.5, 'paid');
+
+delete from sessions where user_id = 4766 and expires_at < now() - interval '645 days';
+
+select id, name, email, created_at from users where id = 6669 and deleted_at is null order by created_at desc limit 10;
+
+update accounts set balance = balance - 6510, updated_at = now() where id = 802 and balance >= 6510;
+
+-- report 2919
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 786 order by total desc;
+
+insert into orders (id, user_id, total, status) values (9920, 11, 9920.11, 'pending'), (11, 9920, 1.5, 'paid');
+
+create table t4335 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 8917 group by kind;
+
+update accounts set balance = balance - 7571, updated_at = now() where id = 853 and balance >= 7571;
+
+-- report 7939
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 174 order by total desc;
+
+select case when x > 8362 then 'big' when x > 47 then 'medium' else 'small' end as size, coalesce(y, 0) + 47 from measurements where z between 47 and 8362;
+
+-- report 8361
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 101 order by total desc;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 1143 group by kind;
+
+insert into orders (id, user_id, total, status) values (7250, 21, 7250.21, 'pending'), (21, 7250, 1.5, 'paid');
+
+update accounts set balance = balance - 8310, updated_at = now() where id = 728 and balance >= 8310;
+
+update accounts set balance = balance - 1525, updated_at = now() where id = 412 and balance >= 1525;
+
+-- report 9913
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 312 order by total desc;
+
+delete from sessions where user_id = 8653 and expires_at < now() - interval '213 days';
+
+delete from sessions where user_id = 5472 and expires_at < now() - interval '276 days';
+
+insert into orders (id, user_id, total, status) values (1227, 716, 1227.716, 'pending'), (716, 1227, 1.5, 'paid');
+
+create table t7667 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+select id, name, email, created_at from users where id = 2762 and deleted_at is null order by created_at desc limit 10;
+
+-- report 5831
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 625 order by total desc;
+
+delete from sessions where user_id = 6432 and expires_at < now() - interval '575 days';
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 2824 group by kind;
+
+-- report 5401
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 734 order by total desc;
+
+delete from sessions where user_id = 4240 and expires_at < now() - interval '987 days';
+
+delete from sessions where user_id = 501 and expires_at < now() - interval '873 days';
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 5187 group by kind;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 4071 group by kind;
+
+-- report 3112
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 75 order by total desc;
+
+update accounts set balance = balance - 9489, updated_at = now() where id = 455 and balance >= 9489;
+
+update accounts set balance = balance - 9933, updated_at = now() where id = 969 and balance >= 9933;
+
+-- report 7527
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 540 order by total desc;
+
+update accounts set balance = balance - 2272, updated_at = now() where id = 798 and balance >= 2272;
+
+update accounts set balance = balance - 7221, updated_at = now() where id = 370 and balance >= 7221;
+
+-- report 6566
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 247 order by total desc;
+
+insert into orders (id, user_id, total, status) values (3379, 736, 3379.736, 'pending'), (736, 3379, 1.5, 'paid');
+
+-- report 1118
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 109 order by total desc;
+
+delete from sessions where user_id = 6505 and expires_at < now() - interval '330 days';
+
+select case when x > 1638 then 'big' when x > 979 then 'medium' else 'small' end as size, coalesce(y, 0) + 979 from measurements where z between 979 and 1638;
+
+update accounts set balance = balance - 737, updated_at = now() where id = 57 and balance >= 737;
+
+select id, name, email, created_at from users where id = 3549 and deleted_at is null order by created_at desc limit 10;
+
+select id, name, email, created_at from users where id = 8102 and deleted_at is null order by created_at desc limit 10;
+
+select case when x > 5611 then 'big' when x > 679 then 'medium' else 'small' end as size, coalesce(y, 0) + 679 from measurements where z between 679 and 5611;
+
+-- report 1935
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 628 order by total desc;
+
+update accounts set balance = balance - 1561, updated_at = now() where id = 228 and balance >= 1561;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 3822 group by kind;
+
+select case when x > 6192 then 'big' when x > 769 then 'medium' else 'small' end as size, coalesce(y, 0) + 769 from measurements where z between 769 and 6192;
+
+update accounts set balance = balance - 3797, updated_at = now() where id = 242 and balance >= 3797;
+
+-- report 7579
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 561 order by total desc;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 3472 group by kind;
+
+-- report 5409
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 509 order by total desc;
+
+insert into orders (id, user_id, total, status) values (3504, 81, 3504.81, 'pending'), (81, 3504, 1.5, 'paid');
+
+select id, name, email, created_at from users where id = 253 and deleted_at is null order by created_at desc limit 10;
+
+select id, name, email, created_at from users where id = 7871 and deleted_at is null order by created_at desc limit 10;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 9507 group by kind;
+
+delete from sessions where user_id = 6553 and expires_at < now() - interval '164 days';
+
+update accounts set balance = balance - 500, updated_at = now() where id = 16 and balance >= 500;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 2379 group by kind;
+
+select id, name, email, created_at from users where id = 9253 and deleted_at is null order by created_at desc limit 10;
+
+-- report 2130
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 82 order by total desc;
+
+select case when x > 4971 then 'big' when x > 928 then 'medium' else 'small' end as size, coalesce(y, 0) + 928 from measurements where z between 928 and 4971;
+
+select id, name, email, created_at from users where id = 582 and deleted_at is null order by created_at desc limit 10;
+
+select id, name, email, created_at from users where id = 8601 and deleted_at is null order by created_at desc limit 10;
+
+update accounts set balance = balance - 702, updated_at = now() where id = 956 and balance >= 702;
+
+-- report 1925
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 443 order by total desc;
+
+insert into orders (id, user_id, total, status) values (3115, 29, 3115.29, 'pending'), (29, 3115, 1.5, 'paid');
+
+select case when x > 2136 then 'big' when x > 763 then 'medium' else 'small' end as size, coalesce(y, 0) + 763 from measurements where z between 763 and 2136;
+
+-- report 3145
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 679 order by total desc;
+
+select case when x > 6385 then 'big' when x > 338 then 'medium' else 'small' end as size, coalesce(y, 0) + 338 from measurements where z between 338 and 6385;
+
+-- report 4258
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 658 order by total desc;
+
+delete from sessions where user_id = 4022 and expires_at < now() - interval '62 days';
+
+update accounts set balance = balance - 5729, updated_at = now() where id = 439 and balance >= 5729;
+
+select id, name, email, created_at from users where id = 5788 and deleted_at is null order by created_at desc limit 10;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 8817 group by kind;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 1149 group by kind;
+
+-- report 1185
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 258 order by total desc;
+
+update accounts set balance = balance - 1583, updated_at = now() where id = 155 and balance >= 1583;
+
+select id, name, email, created_at from users where id = 3332 and deleted_at is null order by created_at desc limit 10;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 736 group by kind;
+
+insert into orders (id, user_id, total, status) values (8403, 481, 8403.481, 'pending'), (481, 8403, 1.5, 'paid');
+
+create table t1627 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+select id, name, email, created_at from users where id = 2075 and deleted_at is null order by created_at desc limit 10;
+
+select id, name, email, created_at from users where id = 7264 and deleted_at is null order by created_at desc limit 10;
+
+update accounts set balance = balance - 6475, updated_at = now() where id = 782 and balance >= 6475;
+
+select case when x > 404 then 'big' when x > 755 then 'medium' else 'small' end as size, coalesce(y, 0) + 755 from measurements where z between 755 and 404;
+
+-- report 1481
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 257 order by total desc;
+
+create table t1406 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+select id, name, email, created_at from users where id = 6296 and deleted_at is null order by created_at desc limit 10;
+
+-- report 5132
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 753 order by total desc;
+
+update accounts set balance = balance - 4265, updated_at = now() where id = 814 and balance >= 4265;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 1920 group by kind;
+
+-- report 1542
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 436 order by total desc;
+
+delete from sessions where user_id = 8237 and expires_at < now() - interval '571 days';
+
+delete from sessions where user_id = 5409 and expires_at < now() - interval '946 days';
+
+create table t8345 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 9571 group by kind;
+
+insert into orders (id, user_id, total, status) values (2126, 669, 2126.669, 'pending'), (669, 2126, 1.5, 'paid');
+
+select case when x > 8582 then 'big' when x > 573 then 'medium' else 'small' end as size, coalesce(y, 0) + 573 from measurements where z between 573 and 8582;
+
+select id, name, email, created_at from users where id = 4774 and deleted_at is null order by created_at desc limit 10;
+
+update accounts set balance = balance - 3277, updated_at = now() where id = 380 and balance >= 3277;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 8538 group by kind;
+
+insert into orders (id, user_id, total, status) values (6710, 354, 6710.354, 'pending'), (354, 6710, 1.5, 'paid');
+
+update accounts set balance = balance - 9420, updated_at = now() where id = 67 and balance >= 9420;
+
+select id, name, email, created_at from users where id = 4924 and deleted_at is null order by created_at desc limit 10;
+
+create table t6842 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+create table t5778 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+create table t8522 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+select id, name, email, created_at from users where id = 8621 and deleted_at is null order by created_at desc limit 10;
+
+update accounts set balance = balance - 5196, updated_at = now() where id = 937 and balance >= 5196;
+
+create table t5367 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+insert into orders (id, user_id, total, status) values (7403, 287, 7403.287, 'pending'), (287, 7403, 1.5, 'paid');
+
+select case when x > 7441 then 'big' when x > 935 then 'medium' else 'small' end as size, coalesce(y, 0) + 935 from measurements where z between 935 and 7441;
+
+create table t6235 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+insert into orders (id, user_id, total, status) values (9486, 821, 9486.821, 'pending'), (821, 9486, 1.5, 'paid');
+
+select id, name, email, created_at from users where id = 2205 and deleted_at is null order by created_at desc limit 10;
+
+select case when x > 9431 then 'big' when x > 874 then 'medium' else 'small' end as size, coalesce(y, 0) + 874 from measurements where z between 874 and 9431;
+
+-- report 4021
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 720 order by total desc;
+
+create table t5925 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+create table t6596 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+select case when x > 9802 then 'big' when x > 349 then 'medium' else 'small' end as size, coalesce(y, 0) + 349 from measurements where z between 349 and 9802;
+
+update accounts set balance = balance - 477, updated_at = now() where id = 152 and balance >= 477;
+
+-- report 3624
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 577 order by total desc;
+
+update accounts set balance = balance - 1848, updated_at = now() where id = 190 and balance >= 1848;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 821 group by kind;
+
+insert into orders (id, user_id, total, status) values (8941, 698, 8941.698, 'pending'), (698, 8941, 1.5, 'paid');
+
+-- report 1753
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 210 order by total desc;
+
+-- report 1095
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 648 order by total desc;
+
+insert into orders (id, user_id, total, status) values (1193, 814, 1193.814, 'pending'), (814, 1193, 1.5, 'paid');
+
+delete from sessions where user_id = 2841 and expires_at < now() - interval '524 days';
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 358 group by kind;
+
+create table t7974 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+-- report 3604
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 913 order by total desc;
+
+delete from sessions where user_id = 9799 and expires_at < now() - interval '506 days';
+
+delete from sessions where user_id = 6971 and expires_at < now() - interval '464 days';
+
+create table t8921 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+delete from sessions where user_id = 7900 and expires_at < now() - interval '744 days';
+
+insert into orders (id, user_id, total, status) values (4204, 418, 4204.418, 'pending'), (418, 4204, 1.5, 'paid');
+
+delete from sessions where user_id = 136 and expires_at < now() - interval '765 days';
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 8427 group by kind;
+
+select case when x > 1252 then 'big' when x > 414 then 'medium' else 'small' end as size, coalesce(y, 0) + 414 from measurements where z between 414 and 1252;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 658 group by kind;
+
+select case when x > 105 then 'big' when x > 195 then 'medium' else 'small' end as size, coalesce(y, 0) + 195 from measurements where z between 195 and 105;
+
+-- report 91
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 554 order by total desc;
+
+insert into orders (id, user_id, total, status) values (4959, 525, 4959.525, 'pending'), (525, 4959, 1.5, 'paid');
+
+create table t8897 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+-- report 8612
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 422 order by total desc;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 9876 group by kind;
+
+-- report 7415
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 310 order by total desc;
+
+update accounts set balance = balance - 8296, updated_at = now() where id = 455 and balance >= 8296;
+
+update accounts set balance = balance - 9012, updated_at = now() where id = 792 and balance >= 9012;
+
+update accounts set balance = balance - 4141, updated_at = now() where id = 652 and balance >= 4141;
+
+select id, name, email, created_at from users where id = 6950 and deleted_at is null order by created_at desc limit 10;
+
+select id, name, email, created_at from users where id = 6036 and deleted_at is null order by created_at desc limit 10;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 4613 group by kind;
+
+select id, name, email, created_at from users where id = 1483 and deleted_at is null order by created_at desc limit 10;
+
+insert into orders (id, user_id, total, status) values (79, 393, 79.393, 'pending'), (393, 79, 1.5, 'paid');
+
+-- report 7609
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 279 order by total desc;
+
+create table t7888 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+create table t6365 (id int primary key, owner_id int not null references users (id), name string not null default '', amount decimal(10, 2), created timestamptz default now(), index (owner_id, created));
+
+insert into orders (id, user_id, total, status) values (7926, 364, 7926.364, 'pending'), (364, 7926, 1.5, 'paid');
+
+update accounts set balance = balance - 6803, updated_at = now() where id = 152 and balance >= 6803;
+
+select id, name, email, created_at from users where id = 2820 and deleted_at is null order by created_at desc limit 10;
+
+-- report 6026
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 879 order by total desc;
+
+update accounts set balance = balance - 9660, updated_at = now() where id = 806 and balance >= 9660;
+
+-- report 6766
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 265 order by total desc;
+
+-- report 6894
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 708 order by total desc;
+
+-- report 7103
+select u.id, count(o.id) as orders, sum(o.total) as total from users as u left join orders as o on o.user_id = u.id where u.created_at > '2020-01-01' group by u.id having count(o.id) > 344 order by total desc;
+
+select case when x > 3531 then 'big' when x > 733 then 'medium' else 'small' end as size, coalesce(y, 0) + 733 from measurements where z between 733 and 3531;
+
+select case when x > 6585 then 'big' when x > 734 then 'medium' else 'small' end as size, coalesce(y, 0) + 734 from measurements where z between 734 and 6585;
+
+with recent as (select * from events where ts > now() - interval '1 hour' and kind in ('a', 'b', 'c')) select kind, count(*) from recent where user_id = 1498 group by kind;
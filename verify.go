@@ -0,0 +1,123 @@
+package sqlfmt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/lexbase"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/parser"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/json"
+)
+
+// verify checks that out, the formatted version of stmt, parses to an
+// equivalent statement and is unchanged when formatted again using cfg. It
+// returns a description of the first problem found, or "" if there is none.
+// exactCase is false if keyword casing isn't deterministic.
+func verify(cfg tree.PrettyCfg, stmt tree.Statement, out string, exactCase bool) string {
+	reparsed, err := parser.Parse(out)
+	if err != nil {
+		return fmt.Sprintf("formatted statement doesn't parse: %v", err)
+	}
+	if len(reparsed) != 1 {
+		return fmt.Sprintf("formatted statement parses to %d statements", len(reparsed))
+	}
+	if want, got := astString(stmt), astString(reparsed[0].AST); want != got {
+		return "formatting changed the statement: " + mismatch(want, got)
+	}
+	_, pretty, err := prettySQL(cfg, out)
+	if err != nil {
+		return fmt.Sprintf("formatted statement doesn't parse: %v", err)
+	}
+	if again := pretty[0]; again != out && (exactCase || !strings.EqualFold(again, out)) {
+		return "formatting isn't idempotent: " + mismatch(out, again)
+	}
+	return ""
+}
+
+// astString returns stmt with all expressions explicitly grouped, so it only
+// differs from the string of another statement if they differ in meaning.
+// Formatting doesn't change the meaning of a statement when it only adds
+// or removes parentheses or reformats JSON, so redundant parentheses are
+// removed and JSON strings are normalized.
+func astString(stmt tree.Statement) string {
+	s := tree.AsStringWithFlags(stmt, tree.FmtParsable|tree.FmtAlwaysGroupExprs)
+	toks := tokenize(s)
+	// match maps the index of each "(" token to its ")".
+	match := map[int]int{}
+	var open []int
+	for i, t := range toks {
+		switch t.id {
+		case '(':
+			open = append(open, i)
+		case ')':
+			if len(open) > 0 {
+				match[open[len(open)-1]] = i
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	// replace maps the index of tokens to change to their new text.
+	replace := map[int]string{}
+	// Remove parentheses directly around another pair of parentheses.
+	for o, c := range match {
+		if inner, ok := match[o+1]; ok && inner == c-1 {
+			replace[o], replace[c] = "", ""
+		}
+	}
+	for i, t := range toks {
+		if t.id == lexbase.SCONST && isJSONCast(toks[i+1:]) {
+			if j, err := json.ParseJSON(t.str); err == nil {
+				replace[i] = lexbase.EscapeSQLString(j.String())
+			}
+		}
+	}
+	var sb strings.Builder
+	prev := 0
+	for i, t := range toks {
+		if r, ok := replace[i]; ok {
+			sb.WriteString(s[prev:t.start])
+			sb.WriteString(r)
+			prev = t.end
+		}
+	}
+	sb.WriteString(s[prev:])
+	return sb.String()
+}
+
+// isJSONCast reports whether toks start with a cast or type annotation to
+// JSON, possibly after closing parentheses.
+func isJSONCast(toks []token) bool {
+	for len(toks) > 0 && toks[0].id == ')' {
+		toks = toks[1:]
+	}
+	if len(toks) < 2 || (toks[0].id != lexbase.TYPECAST && toks[0].id != lexbase.TYPEANNOTATE) {
+		return false
+	}
+	typ := strings.ToLower(toks[1].str)
+	return typ == "json" || typ == "jsonb"
+}
+
+// mismatchContext is the number of bytes shown around the first difference
+// by mismatch.
+const mismatchContext = 30
+
+// mismatch describes where want and got first differ.
+func mismatch(want, got string) string {
+	i := 0
+	for i < len(want) && i < len(got) && want[i] == got[i] {
+		i++
+	}
+	excerpt := func(s string) string {
+		start, end := i-mismatchContext, i+mismatchContext
+		prefix, suffix := "...", "..."
+		if start <= 0 {
+			start, prefix = 0, ""
+		}
+		if end >= len(s) {
+			end, suffix = len(s), ""
+		}
+		return fmt.Sprintf("%q", prefix+s[start:end]+suffix)
+	}
+	return fmt.Sprintf("at byte %d, expected %s, got %s", i, excerpt(want), excerpt(got))
+}
@@ -0,0 +1,44 @@
+package sqlfmt
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/parser"
+)
+
+func TestASTString(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"select 1", "SELECT   1", true},
+		{"select (1)", "select 1", true},
+		{"select ((a + b))", "select a + b", true},
+		{"select (a + b) * c", "select a + b * c", false},
+		{"select a + (b * c)", "select a + b * c", true},
+		{"select f((a))", "select f(a)", true},
+		{"select * from t where (a = 1) and ((b = 2))", "select * from t where a = 1 and b = 2", true},
+		{"select * from t where a = 1 or b = 2 and c = 3", "select * from t where (a = 1 or b = 2) and c = 3", false},
+		{`select '{"a":1,"b":[1,2]}'::jsonb`, `select '{"a": 1, "b": [1, 2]}'::JSONB`, true},
+		{`select ('{"a":1}')::json`, `select '{"a": 1}'::json`, true},
+		{`select '{"a":1}':::jsonb`, `select '{"a": 1}':::jsonb`, true},
+		{`select '{"a":1}'::jsonb`, `select '{"a": 2}'::jsonb`, false},
+		// Only JSON strings are normalized.
+		{`select '{"a":1}'::string`, `select '{"a": 1}'::string`, false},
+		{"select 'a'", "select 'b'", false},
+	}
+	for _, tc := range tests {
+		a, err := parser.ParseOne(tc.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := parser.ParseOne(tc.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		as, bs := astString(a.AST), astString(b.AST)
+		if (as == bs) != tc.same {
+			t.Errorf("%s and %s: got %q and %q, want same %v", tc.a, tc.b, as, bs, tc.same)
+		}
+	}
+}
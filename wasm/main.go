@@ -1,14 +1,15 @@
 package main
 
 import (
+	"context"
 	"syscall/js"
 
-	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
 	"github.com/mjibson/sqlfmt"
 )
 
 func main() {
 	js.Global().Set("FmtSQL", FmtSQL())
+	js.Global().Set("DisplayWidth", DisplayWidth())
 	select {}
 }
 
@@ -20,9 +21,10 @@ func FmtSQL() js.Func {
 		input := args[0].String()
 		width := args[1].Int()
 
-		cfg := tree.DefaultPrettyCfg()
-		cfg.LineWidth = width
-		pretty, err := sqlfmt.FmtSQL(cfg, []string{input})
+		opts := sqlfmt.DefaultOptions()
+		opts.LineWidth = width
+		opts.FinalNewline = sqlfmt.FinalNewlineNever
+		pretty, err := sqlfmt.Format(context.Background(), input, opts)
 		if err != nil {
 			return err.Error()
 		}
@@ -30,3 +32,14 @@ func FmtSQL() js.Func {
 	})
 	return jsonFunc
 }
+
+// DisplayWidth returns a function that takes formatted SQL and returns the
+// number of columns taken by its widest line.
+func DisplayWidth() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		if len(args) != 1 {
+			return "Invalid no of arguments passed"
+		}
+		return sqlfmt.DisplayWidth(args[0].String(), sqlfmt.DefaultOptions().TabWidth)
+	})
+}
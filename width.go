@@ -0,0 +1,262 @@
+package sqlfmt
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/lexbase"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/parser"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"golang.org/x/text/width"
+)
+
+// DisplayWidth returns the number of terminal columns taken by the widest
+// line of s, with tabs stopping every tabWidth columns. Wide East Asian
+// characters and emoji take two columns, and characters that combine with
+// the one before them take none.
+func DisplayWidth(s string, tabWidth int) int {
+	max := 0
+	for _, line := range strings.Split(s, "\n") {
+		if w := lineWidth(line, tabWidth); w > max {
+			max = w
+		}
+	}
+	return max
+}
+
+// lineWidth returns the number of terminal columns taken by line.
+func lineWidth(line string, tabWidth int) int {
+	w := 0
+	prev := rune(0)
+	for _, r := range line {
+		w = advance(w, prev, r, tabWidth)
+		prev = r
+	}
+	return w
+}
+
+// advance returns the column after r, which follows prev at column w.
+func advance(w int, prev, r rune, tabWidth int) int {
+	switch {
+	case r == '\t' && tabWidth > 0:
+		return w + tabWidth - w%tabWidth
+	case prev == '\u200d', isEmojiModifier(r) && prev != 0:
+		// Joined to the previous character.
+		return w
+	case r == '\ufe0f' && prev != 0 && runeWidth(prev) == 1:
+		// Emoji presentation of the previous character.
+		return w + 1
+	}
+	return w + runeWidth(r)
+}
+
+// runeWidth returns the number of terminal columns taken by r on its own.
+func runeWidth(r rune) int {
+	switch {
+	case r < utf8.RuneSelf:
+		if unicode.IsControl(r) {
+			return 0
+		}
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf, unicode.Cc):
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// isEmojiModifier reports whether r is a skin tone modifier.
+func isEmojiModifier(r rune) bool {
+	return r >= 0x1f3fb && r <= 0x1f3ff
+}
+
+// prettySQL parses sql and pretty prints each of its statements using cfg,
+// measuring non-ASCII identifiers in display columns. It returns the parsed
+// statements and their formatted text.
+func prettySQL(cfg tree.PrettyCfg, sql string) (parser.Statements, []string, error) {
+	sub, restore := placeholders(sql)
+	stmts, err := parser.Parse(sub)
+	if err != nil && sub != sql {
+		// Report errors at their place in sql, and let the parser decide
+		// whether sql is valid.
+		sub, restore = sql, func(s string) string { return s }
+		stmts, err = parser.Parse(sql)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	pretty := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		pretty[i] = restore(prettyWithComments(cfg, sub, stmt.AST))
+	}
+	if sub != sql {
+		// Return the statements with their real identifiers.
+		if stmts, err = parser.Parse(sql); err != nil {
+			return nil, nil, err
+		}
+	}
+	return stmts, pretty, nil
+}
+
+// placeholders replaces the identifiers in sql that contain non-ASCII
+// characters with ASCII identifiers whose length is the display width of
+// the original. The layout measures width in bytes, so this makes it
+// measure those identifiers in columns instead. It returns the new SQL and
+// a function that restores the identifiers in formatted output.
+func placeholders(sql string) (string, func(string) string) {
+	identity := func(s string) string { return s }
+	if isASCII(sql) {
+		return sql, identity
+	}
+	toks := tokenize(sql)
+	used := map[string]bool{}
+	for _, t := range toks {
+		if t.id == lexbase.IDENT {
+			used[t.str] = true
+		}
+	}
+	// names maps each identifier to its placeholder and restored to the
+	// formatted identifier for each placeholder.
+	names := map[string]string{}
+	restored := map[string]string{}
+	var sb strings.Builder
+	prev := 0
+	for _, t := range toks {
+		if t.id != lexbase.IDENT || isASCII(t.str) {
+			continue
+		}
+		p, ok := names[t.str]
+		if !ok {
+			var buf bytes.Buffer
+			lexbase.EncodeRestrictedSQLIdent(&buf, t.str, lexbase.EncNoFlags)
+			if p = placeholder(lineWidth(buf.String(), 0), used); p == "" {
+				continue
+			}
+			names[t.str] = p
+			restored[p] = buf.String()
+			used[p] = true
+		}
+		sb.WriteString(sql[prev:t.start])
+		sb.WriteString(p)
+		prev = t.end
+	}
+	if len(names) == 0 {
+		return sql, identity
+	}
+	sb.WriteString(sql[prev:])
+	return sb.String(), func(out string) string {
+		var sb strings.Builder
+		prev := 0
+		for _, t := range tokenize(out) {
+			if s, ok := restored[t.str]; ok && t.id == lexbase.IDENT {
+				sb.WriteString(out[prev:t.start])
+				sb.WriteString(s)
+				prev = t.end
+			}
+		}
+		sb.WriteString(out[prev:])
+		return sb.String()
+	}
+}
+
+// placeholder returns an unused, lowercase identifier of length n that
+// isn't a keyword, or "" if there are none left to try.
+func placeholder(n int, used map[string]bool) string {
+	if n < 1 {
+		return ""
+	}
+	b := make([]byte, n)
+	// Try the first few hundred identifiers in order: "a", "b", ... or
+	// "aa", "ab", ...
+	for k := 0; k < 500; k++ {
+		i := k
+		for j := n - 1; j >= 0; j-- {
+			b[j] = byte('a' + i%26)
+			i /= 26
+		}
+		if i > 0 {
+			return ""
+		}
+		if s := string(b); !used[s] && lexbase.GetKeywordID(s) == lexbase.IDENT {
+			return s
+		}
+	}
+	return ""
+}
+
+// isASCII reports whether s only contains ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// overflows returns the lines of s, a formatted statement, that are wider
+// than limit, with 0-based line numbers.
+func overflows(s string, limit, tabWidth int) []Overflow {
+	var res []Overflow
+	var toks []token
+	off := 0
+	for i, line := range strings.Split(s, "\n") {
+		if w := lineWidth(line, tabWidth); w > limit {
+			if toks == nil {
+				toks = tokenize(s)
+			}
+			res = append(res, Overflow{
+				Line:  i,
+				Width: w,
+				Token: tokenAt(s, toks, off, off+len(line), off+columnOffset(line, limit, tabWidth)),
+			})
+		}
+		off += len(line) + 1
+	}
+	return res
+}
+
+// columnOffset returns the offset in line of the character that ends past
+// col columns.
+func columnOffset(line string, col, tabWidth int) int {
+	w := 0
+	prev := rune(0)
+	for i, r := range line {
+		if w = advance(w, prev, r, tabWidth); w > col {
+			return i
+		}
+		prev = r
+	}
+	return len(line)
+}
+
+// tokenAt returns the token of s, which was split into toks, at offset off
+// of the line s[start:end], or the word at off if it isn't in a token, such
+// as in a comment. If off is in whitespace, the first token or word after
+// it is used, or the first of the line if there is none.
+func tokenAt(s string, toks []token, start, end, off int) string {
+	notSpace := func(r rune) bool { return !unicode.IsSpace(r) }
+	if i := strings.IndexFunc(s[off:end], notSpace); i >= 0 {
+		off += i
+	} else if i := strings.IndexFunc(s[start:end], notSpace); i >= 0 {
+		off = start + i
+	} else {
+		return s[start:end]
+	}
+	for _, t := range toks {
+		if t.start <= off && off < t.end {
+			return s[t.start:t.end]
+		}
+	}
+	wordStart := start + strings.LastIndexFunc(s[start:off], unicode.IsSpace) + 1
+	wordEnd := end
+	if i := strings.IndexFunc(s[off:end], unicode.IsSpace); i >= 0 {
+		wordEnd = off + i
+	}
+	return s[wordStart:wordEnd]
+}
@@ -0,0 +1,33 @@
+package sqlfmt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOverflows(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		limit int
+		want  []Overflow
+	}{
+		{"fits", "SELECT a\nFROM t", 10, nil},
+		{"token", "SELECT aaaaaaaaaa", 10, []Overflow{{Line: 0, Width: 17, Token: "aaaaaaaaaa"}}},
+		{"string", "SELECT 'a b c d e f'", 12, []Overflow{{Line: 0, Width: 20, Token: "'a b c d e f'"}}},
+		{"space before token", "SELECT a,  bbbbbbbbbb", 9, []Overflow{{Line: 0, Width: 21, Token: "bbbbbbbbbb"}}},
+		{"indentation", "SELECT\n\t\t\t\tx", 10, []Overflow{{Line: 1, Width: 17, Token: "x"}}},
+		{"indentation only", "SELECT\n\t\t\t\tx,\n\t\t\t\ty", 10, []Overflow{
+			{Line: 1, Width: 18, Token: "x"},
+			{Line: 2, Width: 17, Token: "y"},
+		}},
+		{"comment", "SELECT 1 -- a longer comment", 15, []Overflow{{Line: 0, Width: 28, Token: "longer"}}},
+		{"comment space", "SELECT 1 -- ab  cd", 14, []Overflow{{Line: 0, Width: 18, Token: "cd"}}},
+		{"trailing space", "SELECT ab      ", 10, []Overflow{{Line: 0, Width: 15, Token: "SELECT"}}},
+	}
+	for _, tc := range tests {
+		if got := overflows(tc.s, tc.limit, 4); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}